@@ -0,0 +1,79 @@
+package httperrorfmt
+
+import (
+	"cmp"
+	"mime"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// weightedValue is one entry of a header such as Accept or Accept-Language
+type weightedValue struct {
+	Value  string
+	Params map[string]string
+	Q      float64
+}
+
+// parseWeighted parses a comma separated list of values with optional
+// parameters and q-values. Entries are sorted by descending q-value, keeping
+// the header order for equal weights. Entries with q=0 are kept so callers can
+// tell refused values from unmentioned ones.
+func parseWeighted(header string) []weightedValue {
+	var values []weightedValue
+	for _, part := range strings.Split(header, ",") {
+		if entry, ok := parseWeightedValue(part); ok {
+			values = append(values, entry)
+		}
+	}
+	slices.SortStableFunc(values, func(a, b weightedValue) int {
+		return cmp.Compare(b.Q, a.Q)
+	})
+	return values
+}
+
+// parseWeightedValue parses a single entry of a weighted list
+func parseWeightedValue(part string) (weightedValue, bool) {
+	fields := strings.Split(part, ";")
+	value := strings.ToLower(strings.TrimSpace(fields[0]))
+	if value == "" {
+		return weightedValue{}, false
+	}
+	entry := weightedValue{Value: value, Q: 1}
+	for _, param := range fields[1:] {
+		key, val, _ := strings.Cut(param, "=")
+		key = strings.ToLower(strings.TrimSpace(key))
+		val = strings.Trim(strings.TrimSpace(val), `"`)
+		if key == "q" {
+			if q, err := strconv.ParseFloat(val, 64); err == nil && q >= 0 && q <= 1 {
+				entry.Q = q
+			}
+			continue
+		}
+		if entry.Params == nil {
+			entry.Params = make(map[string]string)
+		}
+		entry.Params[key] = val
+	}
+	return entry, true
+}
+
+// normalizeMediaType lowercases a media type and its parameter names and
+// sorts the parameters, so equal media types compare equal
+func normalizeMediaType(contentType string) string {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return strings.ToLower(strings.TrimSpace(contentType))
+	}
+	return mime.FormatMediaType(mediaType, params)
+}
+
+// paramsMatch reports whether every parameter in want has the same value in have
+func paramsMatch(want, have map[string]string) bool {
+	for key, value := range want {
+		if have[key] != value {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,33 @@
+package httperrorfmt
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// MethodNotAllowed creates a 405 error recording the methods the resource
+// supports. They are sent in the Allow header RFC 9110 requires, and listed
+// in JSON and HTML bodies.
+func MethodNotAllowed(allowed ...string) *Error {
+	e := New(http.StatusMethodNotAllowed, "Method not allowed")
+	for _, method := range allowed {
+		if method = strings.ToUpper(strings.TrimSpace(method)); method != "" && !slices.Contains(e.allowed, method) {
+			e.allowed = append(e.allowed, method)
+		}
+	}
+	return e
+}
+
+// AllowedMethods returns the methods recorded by MethodNotAllowed
+func (e *Error) AllowedMethods() []string { return e.allowed }
+
+// allowedMethodsOf returns the methods of errors implementing AllowedMethods() []string
+func allowedMethodsOf(err HTTPError) []string {
+	var a interface{ AllowedMethods() []string }
+	if errors.As(err, &a) {
+		return a.AllowedMethods()
+	}
+	return nil
+}
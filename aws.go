@@ -0,0 +1,66 @@
+package httperrorfmt
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// AWSErrorFormatter formats errors in the AWS JSON protocol error shape
+type AWSErrorFormatter struct {
+	// ContentType defaults to application/x-amz-json-1.1
+	ContentType string
+}
+
+// AWSErrorResponse represents an AWS JSON protocol error body
+type AWSErrorResponse struct {
+	Type    string `json:"__type"`
+	Message string `json:"message"`
+}
+
+// Format implements Formatter interface for AWS style error responses. The error
+// type is taken from ErrorCode() string, falling back to an AWS exception name
+// derived from the status.
+func (f *AWSErrorFormatter) Format(w http.ResponseWriter, r *http.Request, err HTTPError) {
+	r, err = normalize(r, err)
+	errorType := errorCode(err)
+	if errorType == "" {
+		errorType = awsErrorType(err.StatusCode())
+	}
+	contentType := f.ContentType
+	if contentType == "" {
+		contentType = "application/x-amz-json-1.1"
+	}
+
+	w.Header().Set("X-Amzn-ErrorType", errorType)
+	writeHeader(w, r, err, contentType)
+
+	data, _ := json.Marshal(AWSErrorResponse{
+		Type:    errorType,
+		Message: publicMessage(err),
+	})
+	w.Write(data)
+}
+
+// awsErrorType maps a status code onto a conventional AWS exception name
+func awsErrorType(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return "ValidationException"
+	case http.StatusUnauthorized:
+		return "UnrecognizedClientException"
+	case http.StatusForbidden:
+		return "AccessDeniedException"
+	case http.StatusNotFound:
+		return "ResourceNotFoundException"
+	case http.StatusConflict:
+		return "ConflictException"
+	case http.StatusTooManyRequests:
+		return "ThrottlingException"
+	case http.StatusServiceUnavailable:
+		return "ServiceUnavailableException"
+	}
+	if status >= 500 {
+		return "InternalServerException"
+	}
+	return "ClientException"
+}
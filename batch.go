@@ -0,0 +1,57 @@
+package httperrorfmt
+
+import (
+	"errors"
+	"net/http"
+)
+
+// BatchItem is the outcome of one item of a batch operation
+type BatchItem struct {
+	Index    int    `json:"index"`
+	Resource string `json:"resource,omitempty"`
+	Status   int    `json:"status"`
+	Message  string `json:"message,omitempty"`
+}
+
+// BatchError reports the per-item outcome of a partially failed batch operation
+type BatchError struct {
+	*errorBase
+	Items []BatchItem
+}
+
+// NewBatchError creates a 207 Multi-Status error for the given items
+func NewBatchError(items ...BatchItem) *BatchError {
+	return &BatchError{
+		errorBase: New(http.StatusMultiStatus, "Some items in the batch failed"),
+		Items:     items,
+	}
+}
+
+// WithStatus sets the overall status, e.g. 400 when every item failed
+func (e *BatchError) WithStatus(status int) *BatchError {
+	e.status = status
+	return e
+}
+
+// Failed returns the number of items that did not succeed
+func (e *BatchError) Failed() int {
+	failed := 0
+	for _, item := range e.Items {
+		if item.Status >= 400 {
+			failed++
+		}
+	}
+	return failed
+}
+
+// BatchItems returns the per-item outcomes
+func (e *BatchError) BatchItems() []BatchItem { return e.Items }
+
+// batchItemsOf returns the batch items carried by an error, if any
+func batchItemsOf(err HTTPError) ([]BatchItem, bool) {
+	var b interface{ BatchItems() []BatchItem }
+	if errors.As(err, &b) {
+		return b.BatchItems(), true
+	}
+	return nil, false
+}
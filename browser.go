@@ -0,0 +1,72 @@
+package httperrorfmt
+
+import (
+	"net/http"
+	"strings"
+)
+
+// programmaticAgents are User-Agent prefixes of common non-browser clients
+var programmaticAgents = []string{
+	"curl/", "wget/", "httpie/", "python-requests/", "python-urllib/",
+	"go-http-client/", "okhttp/", "axios/", "node-fetch", "postmanruntime/",
+	"insomnia/", "java/", "apache-httpclient/",
+}
+
+// IsBrowser guesses whether a request comes from an interactive browser rather
+// than a programmatic client. Fetch metadata is trusted first: navigations are
+// browsers, fetch and XMLHttpRequest calls are not. Without it the Accept header
+// and User-Agent decide.
+func IsBrowser(r *http.Request) bool {
+	if r.Header.Get("X-Requested-With") != "" {
+		return false
+	}
+	switch r.Header.Get("Sec-Fetch-Mode") {
+	case "navigate", "nested-navigate":
+		return true
+	case "cors", "no-cors", "same-origin", "websocket":
+		return false
+	}
+	if r.Header.Get("Sec-Fetch-Dest") == "document" {
+		return true
+	}
+	if strings.Contains(r.Header.Get("Accept"), "text/html") {
+		return true
+	}
+
+	agent := strings.ToLower(r.UserAgent())
+	for _, prefix := range programmaticAgents {
+		if strings.HasPrefix(agent, prefix) {
+			return false
+		}
+	}
+	return strings.HasPrefix(agent, "mozilla/")
+}
+
+// SetBrowserDetection makes requests that accept anything, or send no Accept
+// header at all, get HTML when IsBrowser says they come from a browser and
+// JSON otherwise, instead of the default formatter
+func (cn *ContentNegotiator) SetBrowserDetection(enabled bool) *ContentNegotiator {
+	cn.detectBrowsers = enabled
+	return cn
+}
+
+// detectClient picks HTML or JSON for requests without a specific preference
+func (cn *ContentNegotiator) detectClient(r *http.Request, accept string) (string, bool) {
+	if !cn.detectBrowsers || !acceptsAnything(accept) {
+		return "", false
+	}
+	if IsBrowser(r) {
+		return "text/html", true
+	}
+	return "application/json", true
+}
+
+// acceptsAnything reports whether an Accept header names no specific media type
+func acceptsAnything(accept string) bool {
+	for _, entry := range parseWeighted(accept) {
+		if entry.Q > 0 && entry.Value != "*/*" {
+			return false
+		}
+	}
+	return true
+}
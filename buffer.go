@@ -0,0 +1,60 @@
+package httperrorfmt
+
+import (
+	"bytes"
+	"net/http"
+)
+
+// responseBuffer is an http.ResponseWriter that records a response in memory
+type responseBuffer struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+// newResponseBuffer creates a buffer starting out with a copy of header
+func newResponseBuffer(header http.Header) *responseBuffer {
+	return &responseBuffer{header: header.Clone()}
+}
+
+// Header returns the recorded headers
+func (b *responseBuffer) Header() http.Header {
+	return b.header
+}
+
+// WriteHeader records the first status code written
+func (b *responseBuffer) WriteHeader(status int) {
+	if b.status == 0 {
+		b.status = status
+	}
+}
+
+// Write records body bytes
+func (b *responseBuffer) Write(p []byte) (int, error) {
+	if b.status == 0 {
+		b.status = http.StatusOK
+	}
+	return b.body.Write(p)
+}
+
+// response returns the recorded response
+func (b *responseBuffer) response() *Response {
+	status := b.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	return &Response{Status: status, Header: b.header, Body: b.body.Bytes()}
+}
+
+// writeResponse replaces the headers of w with those of resp and writes it out
+func writeResponse(w http.ResponseWriter, resp *Response) {
+	header := w.Header()
+	for key := range header {
+		delete(header, key)
+	}
+	for key, values := range resp.Header {
+		header[key] = values
+	}
+	w.WriteHeader(resp.Status)
+	w.Write(resp.Body)
+}
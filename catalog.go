@@ -0,0 +1,176 @@
+package httperrorfmt
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// CatalogEntry describes one public error code in a catalog
+type CatalogEntry struct {
+	Code        string `json:"code"`
+	Status      int    `json:"status"`
+	Title       string `json:"title"`
+	Message     string `json:"message"`
+	Description string `json:"description,omitempty"`
+	DocURL      string `json:"doc_url,omitempty"`
+}
+
+// Catalog returns the public error codes of the registry in registration
+// order. Internal codes are left out, since clients never see them.
+func (reg *Registry) Catalog() []CatalogEntry {
+	var entries []CatalogEntry
+	for _, def := range reg.Definitions() {
+		if def.Internal {
+			continue
+		}
+		title := def.Title
+		if title == "" {
+			title = statusText(def.Status)
+		}
+		entries = append(entries, CatalogEntry{
+			Code:        def.Code,
+			Status:      def.Status,
+			Title:       title,
+			Message:     def.Message,
+			Description: def.Description,
+			DocURL:      def.DocURL,
+		})
+	}
+	return entries
+}
+
+// WriteCatalogJSON writes the catalog as an indented JSON array
+func (reg *Registry) WriteCatalogJSON(w io.Writer) error {
+	entries := reg.Catalog()
+	if entries == nil {
+		entries = []CatalogEntry{}
+	}
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+// WriteCatalogMarkdown writes the catalog as a Markdown document with a
+// summary table and a section per code
+func (reg *Registry) WriteCatalogMarkdown(w io.Writer) error {
+	entries := reg.Catalog()
+	var b strings.Builder
+	b.WriteString("# Error Codes\n\n")
+	b.WriteString("| Code | Status | Title |\n|------|--------|-------|\n")
+	for _, e := range entries {
+		fmt.Fprintf(&b, "| [`%s`](#%s) | %d | %s |\n", e.Code, markdownAnchor(e.Code), e.Status, markdownCell(e.Title))
+	}
+	for _, e := range entries {
+		fmt.Fprintf(&b, "\n## %s\n\n", e.Code)
+		fmt.Fprintf(&b, "**%d %s**", e.Status, statusText(e.Status))
+		if e.Title != statusText(e.Status) {
+			fmt.Fprintf(&b, ": %s", markdownText(e.Title))
+		}
+		b.WriteByte('\n')
+		if e.Message != "" {
+			fmt.Fprintf(&b, "\nMessage: %s\n", markdownText(e.Message))
+		}
+		if e.Description != "" {
+			fmt.Fprintf(&b, "\n%s\n", e.Description)
+		}
+		if e.DocURL != "" {
+			fmt.Fprintf(&b, "\n[Documentation](%s)\n", e.DocURL)
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteCatalogHTML writes the catalog as a standalone HTML page
+func (reg *Registry) WriteCatalogHTML(w io.Writer) error {
+	return catalogTemplate.Execute(w, reg.Catalog())
+}
+
+// catalogTemplate renders the HTML catalog page
+var catalogTemplate = template.Must(template.New("catalog").Parse(`<!DOCTYPE html>
+<html>
+<head>
+    <title>Error Codes</title>
+    <style>
+        body { font-family: Arial, sans-serif; margin: 40px; }
+        table { border-collapse: collapse; margin-bottom: 40px; }
+        th, td { border-bottom: 1px solid #ddd; padding: 6px 12px; text-align: left; }
+        .error-code { font-family: monospace; }
+        .error-details { font-size: 14px; color: #666; }
+    </style>
+</head>
+<body>
+    <h1>Error Codes</h1>
+    <table>
+        <tr><th>Code</th><th>Status</th><th>Title</th></tr>
+        {{- range .}}
+        <tr><td class="error-code"><a href="#{{.Code}}">{{.Code}}</a></td><td>{{.Status}}</td><td>{{.Title}}</td></tr>
+        {{- end}}
+    </table>
+    {{- range .}}
+    <section id="{{.Code}}">
+        <h2 class="error-code">{{.Code}}</h2>
+        <p><strong>{{.Status}}</strong> {{.Title}}</p>
+        {{- if .Message}}
+        <p class="error-details">Message: {{.Message}}</p>
+        {{- end}}
+        {{- if .Description}}
+        <p>{{.Description}}</p>
+        {{- end}}
+        {{- if .DocURL}}
+        <p class="error-details"><a href="{{.DocURL}}">Documentation</a></p>
+        {{- end}}
+    </section>
+    {{- end}}
+</body>
+</html>
+`))
+
+// CatalogHandler serves the catalog of reg as HTML, Markdown or JSON, picked
+// by the Accept header. JSON is the default.
+func CatalogHandler(reg *Registry) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		write, contentType := reg.WriteCatalogJSON, "application/json; charset=utf-8"
+		for _, entry := range parseWeighted(r.Header.Get("Accept")) {
+			if entry.Q == 0 {
+				continue
+			}
+			if entry.Value == "text/html" {
+				write, contentType = reg.WriteCatalogHTML, "text/html; charset=utf-8"
+				break
+			}
+			if entry.Value == "text/markdown" {
+				write, contentType = reg.WriteCatalogMarkdown, "text/markdown; charset=utf-8"
+				break
+			}
+			if entry.Value == "application/json" {
+				break
+			}
+		}
+		w.Header().Set("Content-Type", contentType)
+		addVary(w.Header(), "Accept")
+		write(w)
+	})
+}
+
+// markdownAnchor returns the heading anchor GitHub style renderers give a code
+func markdownAnchor(code string) string {
+	return strings.ToLower(strings.NewReplacer(" ", "-", ".", "").Replace(code))
+}
+
+// markdownCell escapes text for a Markdown table cell
+func markdownCell(s string) string {
+	return strings.ReplaceAll(markdownText(s), "|", `\|`)
+}
+
+// markdownText escapes the characters that would start Markdown markup
+func markdownText(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "*", `\*`, "_", `\_`, "`", "\\`", "[", `\[`, "<", "&lt;", "\n", " ").Replace(s)
+}
@@ -0,0 +1,20 @@
+package httperrorfmt
+
+import "errors"
+
+// Cause describes one contributing reason for an error, usually tied to a field.
+// Errors expose their causes by implementing Causes() []Cause.
+type Cause struct {
+	Reason  string `json:"reason,omitempty" xml:"reason,omitempty"`
+	Message string `json:"message,omitempty" xml:"message,omitempty"`
+	Field   string `json:"field,omitempty" xml:"field,omitempty"`
+}
+
+// causesOf returns the causes carried by an error, if any
+func causesOf(err HTTPError) []Cause {
+	var c interface{ Causes() []Cause }
+	if errors.As(err, &c) {
+		return c.Causes()
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package httperrorfmt
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// Challenge is an authentication challenge sent in WWW-Authenticate, or in
+// Proxy-Authenticate for 407 responses
+type Challenge struct {
+	Scheme string
+	params []challengeParam
+}
+
+// challengeParam is one auth-param of a challenge
+type challengeParam struct {
+	name, value string
+	token       bool
+}
+
+// BasicChallenge creates an RFC 7617 Basic challenge announcing UTF-8 credentials
+func BasicChallenge(realm string) Challenge {
+	return Challenge{Scheme: "Basic"}.Param("realm", realm).Param("charset", "UTF-8")
+}
+
+// BearerChallenge creates an RFC 6750 Bearer challenge. Add "scope", "error",
+// "error_description" and "error_uri" with Param. Requests that carried no
+// token should get the challenge without an error (RFC 6750 section 3.1).
+func BearerChallenge(realm string) Challenge {
+	return Challenge{Scheme: "Bearer"}.Param("realm", realm)
+}
+
+// DigestChallenge creates an RFC 7616 Digest challenge using SHA-256 and
+// qop=auth. Add "opaque", "domain" or "stale" with Param.
+func DigestChallenge(realm, nonce string) Challenge {
+	return Challenge{Scheme: "Digest"}.
+		Param("realm", realm).
+		Param("qop", "auth").
+		Param("algorithm", "SHA-256").
+		Param("nonce", nonce)
+}
+
+// tokenParams are auth-params whose values are sent as tokens, not quoted
+var tokenParams = []string{"algorithm", "stale", "userhash"}
+
+// Param returns a copy of the challenge with an added auth-param. Empty
+// values are left out. Values are quoted, except those of parameters the
+// Digest scheme defines as tokens.
+func (c Challenge) Param(name, value string) Challenge {
+	if value == "" {
+		return c
+	}
+	token := slices.Contains(tokenParams, strings.ToLower(name)) &&
+		strings.IndexFunc(value, func(r rune) bool { return !isTokenChar(r) }) < 0
+	// Clip so copies of a challenge never share appended params
+	c.params = append(slices.Clip(c.params), challengeParam{name: name, value: value, token: token})
+	return c
+}
+
+// String renders the challenge as a header value
+func (c Challenge) String() string {
+	if len(c.params) == 0 {
+		return c.Scheme
+	}
+	params := make([]string, len(c.params))
+	for i, p := range c.params {
+		if p.token {
+			params[i] = p.name + "=" + p.value
+		} else {
+			params[i] = p.name + "=" + quoteParam(headerSafe(p.value))
+		}
+	}
+	return c.Scheme + " " + strings.Join(params, ", ")
+}
+
+// WithChallenge attaches challenges, sent in WWW-Authenticate with 401
+// responses and in Proxy-Authenticate with 407 responses
+func (e *Error) WithChallenge(challenges ...Challenge) *Error {
+	e.challenges = append(e.challenges, challenges...)
+	return e
+}
+
+// Challenges returns the attached challenges
+func (e *Error) Challenges() []Challenge { return e.challenges }
+
+// setChallengeHeaders sends the challenges of errors implementing
+// Challenges() []Challenge, one header line each
+func setChallengeHeaders(header http.Header, err HTTPError) {
+	var c interface{ Challenges() []Challenge }
+	if !errors.As(err, &c) || len(c.Challenges()) == 0 {
+		return
+	}
+	var name string
+	switch err.StatusCode() {
+	case http.StatusUnauthorized:
+		name = "WWW-Authenticate"
+	case http.StatusProxyAuthRequired:
+		name = "Proxy-Authenticate"
+	default:
+		return
+	}
+	header.Del(name)
+	for _, challenge := range c.Challenges() {
+		header.Add(name, challenge.String())
+	}
+}
@@ -0,0 +1,60 @@
+package httperrorfmt
+
+import (
+	"net/http"
+	"slices"
+	"unicode/utf8"
+)
+
+// latin1Names are the Accept-Charset names of ISO-8859-1
+var latin1Names = []string{"iso-8859-1", "iso_8859-1", "latin1", "l1"}
+
+// prefersLatin1 reports whether the request's Accept-Charset header ranks
+// ISO-8859-1 above UTF-8. Charsets that aren't listed get the q-value of "*",
+// or 0 without one.
+func prefersLatin1(r *http.Request) bool {
+	accept := r.Header.Get("Accept-Charset")
+	if accept == "" {
+		return false
+	}
+	utf8Q, latin1Q, anyQ := -1.0, -1.0, 0.0
+	for _, entry := range parseWeighted(accept) {
+		switch {
+		case entry.Value == "utf-8" || entry.Value == "utf8":
+			utf8Q = max(utf8Q, entry.Q)
+		case slices.Contains(latin1Names, entry.Value):
+			latin1Q = max(latin1Q, entry.Q)
+		case entry.Value == "*":
+			anyQ = entry.Q
+		}
+	}
+	if utf8Q < 0 {
+		utf8Q = anyQ
+	}
+	if latin1Q < 0 {
+		latin1Q = anyQ
+	}
+	return latin1Q > utf8Q
+}
+
+// latin1Writer transcodes UTF-8 writes to ISO-8859-1
+type latin1Writer struct {
+	http.ResponseWriter
+}
+
+func (w *latin1Writer) Write(p []byte) (int, error) {
+	n := len(p)
+	out := make([]byte, 0, len(p))
+	for len(p) > 0 {
+		r, size := utf8.DecodeRune(p)
+		if r > 0xff {
+			r = '?'
+		}
+		out = append(out, byte(r))
+		p = p[size:]
+	}
+	if _, err := w.ResponseWriter.Write(out); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
@@ -0,0 +1,132 @@
+// Package chierr wires httperrorfmt into chi routers: handlers that return
+// errors, a panic recoverer and the not found and method not allowed slots
+package chierr
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/perbu/httperrorfmt"
+)
+
+// HandlerFunc is a handler that returns its error instead of writing it
+type HandlerFunc func(w http.ResponseWriter, r *http.Request) error
+
+// Errors renders the errors of a chi application through one formatter,
+// usually a shared ContentNegotiator
+type Errors struct {
+	// Formatter renders errors. Nil means httperrorfmt.Default.
+	Formatter httperrorfmt.Formatter
+	// Mapper converts returned errors that aren't HTTPErrors. Nil turns them
+	// into 500s.
+	Mapper *httperrorfmt.ErrorMapper
+}
+
+// New creates an Errors rendering through formatter
+func New(formatter httperrorfmt.Formatter, mapper *httperrorfmt.ErrorMapper) *Errors {
+	return &Errors{Formatter: formatter, Mapper: mapper}
+}
+
+// Handler adapts fn to an http.HandlerFunc that renders its returned error
+func (e *Errors) Handler(fn HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := fn(w, r); err != nil {
+			e.Format(w, r, err)
+		}
+	}
+}
+
+// Format renders err, converting it with Mapper first
+func (e *Errors) Format(w http.ResponseWriter, r *http.Request, err error) {
+	e.formatter().Format(w, r, e.Mapper.Map(err))
+}
+
+// Recoverer replaces chi's middleware.Recoverer, rendering panics as 500s
+// through Formatter
+func (e *Errors) Recoverer(next http.Handler) http.Handler {
+	return httperrorfmt.Recover(e.formatter())(next)
+}
+
+// probedMethods are the methods tried to find those a route allows
+var probedMethods = []string{
+	http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+	http.MethodDelete, http.MethodOptions, http.MethodConnect, http.MethodTrace,
+}
+
+// Install sets the not found and method not allowed handlers of r, a root
+// router. chi doesn't tell custom 405 handlers which methods the route allows,
+// so they are found by matching the path against r with each method, for the
+// Allow header and the body.
+func (e *Errors) Install(r chi.Router) {
+	r.NotFound(httperrorfmt.NotFoundHandler(e.formatter()).ServeHTTP)
+	r.MethodNotAllowed(func(w http.ResponseWriter, req *http.Request) {
+		path := req.URL.RawPath
+		if path == "" {
+			path = req.URL.Path
+		}
+		var allowed []string
+		for _, method := range probedMethods {
+			if r.Match(chi.NewRouteContext(), method, path) {
+				allowed = append(allowed, method)
+			}
+		}
+		e.formatter().Format(w, req, httperrorfmt.MethodNotAllowed(allowed...))
+	})
+}
+
+// Router wraps r so routes can be registered with error-returning handlers
+func (e *Errors) Router(r chi.Router) *Router {
+	return &Router{Router: r, errors: e}
+}
+
+// formatter returns Formatter or the package default
+func (e *Errors) formatter() httperrorfmt.Formatter {
+	return httperrorfmt.OrDefault(e.Formatter)
+}
+
+// Router is a chi router whose route methods take a HandlerFunc. The
+// embedded chi.Router remains available for middleware, mounts and plain
+// handlers.
+type Router struct {
+	chi.Router
+	errors *Errors
+}
+
+// Method routes method and pattern to fn
+func (rt *Router) Method(method, pattern string, fn HandlerFunc) {
+	rt.Router.Method(method, pattern, rt.errors.Handler(fn))
+}
+
+// Get routes GET requests for pattern to fn
+func (rt *Router) Get(pattern string, fn HandlerFunc) { rt.Method(http.MethodGet, pattern, fn) }
+
+// Post routes POST requests for pattern to fn
+func (rt *Router) Post(pattern string, fn HandlerFunc) { rt.Method(http.MethodPost, pattern, fn) }
+
+// Put routes PUT requests for pattern to fn
+func (rt *Router) Put(pattern string, fn HandlerFunc) { rt.Method(http.MethodPut, pattern, fn) }
+
+// Patch routes PATCH requests for pattern to fn
+func (rt *Router) Patch(pattern string, fn HandlerFunc) { rt.Method(http.MethodPatch, pattern, fn) }
+
+// Delete routes DELETE requests for pattern to fn
+func (rt *Router) Delete(pattern string, fn HandlerFunc) { rt.Method(http.MethodDelete, pattern, fn) }
+
+// Group creates an inline group sharing the pattern of rt, like chi's Group
+func (rt *Router) Group(fn func(r *Router)) *Router {
+	group := rt.errors.Router(rt.Router.With())
+	if fn != nil {
+		fn(group)
+	}
+	return group
+}
+
+// Route mounts a subrouter at pattern, like chi's Route
+func (rt *Router) Route(pattern string, fn func(r *Router)) *Router {
+	sub := rt.errors.Router(chi.NewRouter())
+	if fn != nil {
+		fn(sub)
+	}
+	rt.Router.Mount(pattern, sub.Router)
+	return sub
+}
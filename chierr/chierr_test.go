@@ -0,0 +1,168 @@
+package chierr
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/perbu/httperrorfmt"
+)
+
+// body is the part of JSON error bodies the tests check
+type body struct {
+	Error  string `json:"error"`
+	Status int    `json:"status"`
+}
+
+// serve sends a request for method and target through h
+func serve(t *testing.T, h http.Handler, method, target string) (*httptest.ResponseRecorder, body) {
+	t.Helper()
+	req := httptest.NewRequest(method, target, nil)
+	req.Header.Set("Accept", "application/json")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	var b body
+	if rec.Body.Len() > 0 {
+		if err := json.Unmarshal(rec.Body.Bytes(), &b); err != nil {
+			t.Fatalf("%s %s: invalid JSON body %q: %v", method, target, rec.Body.String(), err)
+		}
+	}
+	return rec, b
+}
+
+func newErrors() *Errors {
+	return New(&httperrorfmt.JSONFormatter{}, nil)
+}
+
+func TestInstallNotFound(t *testing.T) {
+	r := chi.NewRouter()
+	e := newErrors()
+	e.Install(r)
+	r.Get("/users", func(w http.ResponseWriter, r *http.Request) {})
+
+	rec, b := serve(t, r, http.MethodGet, "/missing")
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusNotFound)
+	}
+	if got := rec.Header().Get("Content-Type"); got != "application/json; charset=utf-8" {
+		t.Errorf("Content-Type = %q", got)
+	}
+	if b.Status != http.StatusNotFound {
+		t.Errorf("body status = %d, want %d", b.Status, http.StatusNotFound)
+	}
+}
+
+func TestInstallMethodNotAllowed(t *testing.T) {
+	r := chi.NewRouter()
+	e := newErrors()
+	e.Install(r)
+	r.Get("/users/{id}", func(w http.ResponseWriter, r *http.Request) {})
+	r.Delete("/users/{id}", func(w http.ResponseWriter, r *http.Request) {})
+	r.Post("/users", func(w http.ResponseWriter, r *http.Request) {})
+
+	rec, b := serve(t, r, http.MethodPut, "/users/42")
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusMethodNotAllowed)
+	}
+	if got, want := rec.Header().Get("Allow"), "GET, DELETE"; got != want {
+		t.Errorf("Allow = %q, want %q", got, want)
+	}
+	if b.Status != http.StatusMethodNotAllowed {
+		t.Errorf("body status = %d, want %d", b.Status, http.StatusMethodNotAllowed)
+	}
+
+	rec, _ = serve(t, r, http.MethodGet, "/users")
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusMethodNotAllowed)
+	}
+	if got, want := rec.Header().Get("Allow"), "POST"; got != want {
+		t.Errorf("Allow = %q, want %q", got, want)
+	}
+}
+
+func TestRecoverer(t *testing.T) {
+	r := chi.NewRouter()
+	e := newErrors()
+	r.Use(e.Recoverer)
+	r.Get("/panic", func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})
+
+	rec, b := serve(t, r, http.MethodGet, "/panic")
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusInternalServerError)
+	}
+	if b.Status != http.StatusInternalServerError {
+		t.Errorf("body status = %d, want %d", b.Status, http.StatusInternalServerError)
+	}
+}
+
+func TestRouterReturnedErrors(t *testing.T) {
+	errMissing := errors.New("no such widget")
+	mapper := httperrorfmt.NewErrorMapper(func(err error) httperrorfmt.HTTPError {
+		if errors.Is(err, errMissing) {
+			return httperrorfmt.Wrap(err, http.StatusNotFound, "Widget not found")
+		}
+		return nil
+	})
+
+	r := chi.NewRouter()
+	e := New(&httperrorfmt.JSONFormatter{}, mapper)
+	rt := e.Router(r)
+	rt.Get("/ok", func(w http.ResponseWriter, r *http.Request) error {
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	})
+	rt.Get("/teapot", func(w http.ResponseWriter, r *http.Request) error {
+		return httperrorfmt.New(http.StatusTeapot, "Short and stout")
+	})
+	rt.Group(func(g *Router) {
+		g.Use(func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("X-Group", "1")
+				next.ServeHTTP(w, r)
+			})
+		})
+		g.Get("/widgets/{id}", func(w http.ResponseWriter, r *http.Request) error {
+			return errMissing
+		})
+		g.Post("/widgets", func(w http.ResponseWriter, r *http.Request) error {
+			return errors.New("database is on fire")
+		})
+	})
+
+	tests := []struct {
+		method, target string
+		status         int
+		message        string
+		group          bool
+	}{
+		{http.MethodGet, "/ok", http.StatusNoContent, "", false},
+		{http.MethodGet, "/teapot", http.StatusTeapot, "Short and stout", false},
+		{http.MethodGet, "/widgets/7", http.StatusNotFound, "Widget not found", true},
+		{http.MethodPost, "/widgets", http.StatusInternalServerError, "", true},
+	}
+	for _, tt := range tests {
+		rec, b := serve(t, r, tt.method, tt.target)
+		if rec.Code != tt.status {
+			t.Errorf("%s %s: status = %d, want %d", tt.method, tt.target, rec.Code, tt.status)
+			continue
+		}
+		if tt.message != "" && b.Error != tt.message {
+			t.Errorf("%s %s: error = %q, want %q", tt.method, tt.target, b.Error, tt.message)
+		}
+		if got := rec.Header().Get("X-Group") == "1"; got != tt.group {
+			t.Errorf("%s %s: group middleware ran = %v, want %v", tt.method, tt.target, got, tt.group)
+		}
+	}
+
+	// Internal errors must not leak into the body
+	_, b := serve(t, r, http.MethodPost, "/widgets")
+	if b.Error == "database is on fire" {
+		t.Errorf("internal error leaked into the body")
+	}
+}
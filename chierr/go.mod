@@ -0,0 +1,10 @@
+module github.com/perbu/httperrorfmt/chierr
+
+go 1.25.0
+
+replace github.com/perbu/httperrorfmt => ../
+
+require (
+	github.com/go-chi/chi/v5 v5.3.2
+	github.com/perbu/httperrorfmt v0.0.0-00010101000000-000000000000
+)
@@ -0,0 +1,74 @@
+package httperrorfmt
+
+import (
+	"errors"
+	"net/http"
+)
+
+// Classification sorts errors by who is at fault and how to react, e.g. to
+// route alerts: client faults need no attention, server faults page the
+// owning team, dependency failures the team of the dependency
+type Classification string
+
+// Classifications of errors
+const (
+	// ClientFault is a request the client has to change, such as a 400 or 404
+	ClientFault Classification = "client_fault"
+	// ServerFault is a failure of the server itself, such as a 500
+	ServerFault Classification = "server_fault"
+	// Transient is a failure that may go away when the request is retried,
+	// such as a 429 or 503
+	Transient Classification = "transient"
+	// Security is a request refused for authentication or authorization, such
+	// as a 401 or 403
+	Security Classification = "security"
+	// Dependency is a failure of another service the server relies on, such as
+	// a 502 or 504
+	Dependency Classification = "dependency"
+)
+
+// WithClassification sets the classification, overriding the one derived
+// from the status
+func (e *Error) WithClassification(c Classification) *Error {
+	e.classification = c
+	return e
+}
+
+// Classification returns the classification set with WithClassification
+func (e *Error) Classification() Classification { return e.classification }
+
+// ClassificationOf returns the classification of err: the one it reports
+// through Classification() Classification, Transient for errors marked
+// transient, or else the one derived from its status with ClassifyStatus
+func ClassificationOf(err HTTPError) Classification {
+	var c interface{ Classification() Classification }
+	if errors.As(err, &c) && c.Classification() != "" {
+		return c.Classification()
+	}
+	if IsTransient(err) {
+		return Transient
+	}
+	return ClassifyStatus(err.StatusCode())
+}
+
+// ClassifyStatus derives a classification from a status: 401, 403 and 407
+// are Security, 408, 429 and 503 Transient, 502 and 504 Dependency, other
+// 4xx ClientFault and other 5xx ServerFault. Statuses below 400 give "".
+func ClassifyStatus(status int) Classification {
+	switch status {
+	case http.StatusUnauthorized, http.StatusForbidden, http.StatusProxyAuthRequired:
+		return Security
+	case http.StatusRequestTimeout, http.StatusTooManyRequests, http.StatusServiceUnavailable:
+		return Transient
+	case http.StatusBadGateway, http.StatusGatewayTimeout:
+		return Dependency
+	}
+	switch {
+	case status >= 500:
+		return ServerFault
+	case status >= 400:
+		return ClientFault
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,146 @@
+package httperrorfmt
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// maxErrorBody limits how much of an error response Decode reads
+const maxErrorBody = 1 << 20
+
+// RemoteError is an error response received from a server. Decode fills it
+// from the bodies this package renders, so clients get the message, code, id
+// and causes the server sent.
+type RemoteError struct {
+	*errorBase
+	// Header holds the response headers
+	Header http.Header
+	// Body is the raw response body, up to 1 MiB
+	Body []byte
+
+	errorID string
+	docURL  string
+	causes  []Cause
+}
+
+// ErrorID returns the id the server gave the error
+func (e *RemoteError) ErrorID() string { return e.errorID }
+
+// DocURL returns the documentation URL the server sent
+func (e *RemoteError) DocURL() string { return e.docURL }
+
+// Causes returns the causes the server sent
+func (e *RemoteError) Causes() []Cause { return e.causes }
+
+// remoteBody holds the members of the JSON error formats Decode understands:
+// ErrorResponse, problem details and vnd.error
+type remoteBody struct {
+	Error       json.RawMessage `json:"error"`
+	Message     string          `json:"message"`
+	Detail      string          `json:"detail"`
+	Title       string          `json:"title"`
+	Code        string          `json:"code"`
+	ErrorCode   string          `json:"error_code"`
+	ErrorID     string          `json:"error_id"`
+	HelpURL     string          `json:"help_url"`
+	Causes      []Cause         `json:"causes"`
+	Remediation *Remediation    `json:"remediation"`
+}
+
+// Decode reads an error response into a RemoteError and closes its body.
+// JSON, problem details and vnd.error bodies provide the message, code, error
+// id, documentation URL, causes and remediation; plain text bodies provide
+// the message on their first line. Other bodies leave the status text as the
+// message. Retry-After and the retry hints of the Retryable and
+// Idempotency-Safe headers are kept.
+func Decode(resp *http.Response) *RemoteError {
+	var body []byte
+	if resp.Body != nil {
+		body, _ = io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		io.Copy(io.Discard, io.LimitReader(resp.Body, maxErrorBody))
+		resp.Body.Close()
+	}
+
+	internal := "remote error: " + resp.Status
+	if req := resp.Request; req != nil && req.URL != nil {
+		u := *req.URL
+		u.User, u.RawQuery, u.Fragment = nil, "", ""
+		internal = fmt.Sprintf("remote error: %s %s: %s", req.Method, u.String(), resp.Status)
+	}
+	e := &RemoteError{
+		errorBase: New(resp.StatusCode, statusText(resp.StatusCode)).WithInternal(internal),
+		Header:    resp.Header.Clone(),
+		Body:      body,
+	}
+	if delay, ok := RetryAfter(resp.Header); ok {
+		e.WithRetryAfter(delay)
+	}
+	e.retryHints = parseRetryHints(resp.Header)
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	switch {
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		e.decodeJSON(body)
+	case mediaType == "text/plain":
+		// TextFormatter puts details on the lines after the message
+		message, _, _ := strings.Cut(strings.TrimSpace(string(body)), "\n")
+		if message != "" {
+			e.public = message
+		}
+	}
+	return e
+}
+
+// decodeJSON fills the error from a JSON error body
+func (e *RemoteError) decodeJSON(data []byte) {
+	var body remoteBody
+	if json.Unmarshal(data, &body) != nil {
+		return
+	}
+	var message string
+	json.Unmarshal(body.Error, &message)
+	for _, candidate := range []string{message, body.Detail, body.Message, body.Title} {
+		if candidate != "" {
+			e.public = candidate
+			break
+		}
+	}
+	// ErrorResponse sends the status text as code and the application code as
+	// error_code; only application codes count
+	switch {
+	case body.ErrorCode != "":
+		e.code = body.ErrorCode
+	case body.Code != "" && body.Code != statusText(e.status):
+		e.code = body.Code
+	}
+	e.errorID, e.docURL, e.causes = body.ErrorID, body.HelpURL, body.Causes
+	e.remediation = body.Remediation
+}
+
+// ErrorTransport is an http.RoundTripper that turns error responses into
+// errors. Responses with a status of 400 or above are decoded with Decode and
+// returned as the error of RoundTrip, so client code can use errors.As with a
+// *RemoteError instead of checking statuses. http.Client wraps the error in a
+// *url.Error, which errors.As sees through. Redirects and other responses
+// below 400 pass through unchanged.
+type ErrorTransport struct {
+	// Base performs the requests. Nil means http.DefaultTransport.
+	Base http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *ErrorTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	resp, err := base.RoundTrip(req)
+	if err != nil || resp.StatusCode < 400 {
+		return resp, err
+	}
+	return nil, Decode(resp)
+}
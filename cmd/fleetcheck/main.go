@@ -0,0 +1,42 @@
+// Command fleetcheck probes services with failing requests and reports where
+// their error responses differ.
+//
+// Usage:
+//
+//	fleetcheck [-timeout 10s] [-concurrency 8] https://users.internal https://orders.internal ...
+//
+// It exits with status 1 when services disagree or probes fail and 2 on
+// usage errors.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/perbu/httperrorfmt/fleetcheck"
+)
+
+func main() {
+	timeout := flag.Duration("timeout", 10*time.Second, "timeout per request")
+	concurrency := flag.Int("concurrency", 8, "maximum requests in flight")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: fleetcheck [flags] service-url...\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	checker := &fleetcheck.Checker{Client: &http.Client{Timeout: *timeout}, Concurrency: *concurrency}
+	report := checker.Check(context.Background(), flag.Args())
+	report.WriteText(os.Stdout)
+	if !report.OK() {
+		os.Exit(1)
+	}
+}
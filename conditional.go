@@ -0,0 +1,75 @@
+package httperrorfmt
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// ConditionalFormatter uses Then for errors and requests matching When and
+// Default for the rest; combine several with Chain.
+type ConditionalFormatter struct {
+	When func(r *http.Request, err HTTPError) bool
+	Then Formatter
+	// Default formats what doesn't match. Nil means the package Default.
+	// Chain ignores it in favour of its fallback.
+	Default Formatter
+}
+
+// Format implements Formatter interface
+func (f ConditionalFormatter) Format(w http.ResponseWriter, r *http.Request, err HTTPError) {
+	r, err = normalize(r, err)
+	if f.matches(r, err) {
+		OrDefault(f.Then).Format(w, r, err)
+		return
+	}
+	OrDefault(f.Default).Format(w, r, err)
+}
+
+// matches reports whether the condition holds
+func (f ConditionalFormatter) matches(r *http.Request, err HTTPError) bool {
+	return f.When != nil && f.When(r, err)
+}
+
+// Chain returns a formatter that uses the first conditional formatter whose
+// condition holds, and fallback when none does
+func Chain(fallback Formatter, conditions ...ConditionalFormatter) Formatter {
+	return &chainFormatter{conditions: conditions, fallback: fallback}
+}
+
+// chainFormatter tries conditional formatters in order
+type chainFormatter struct {
+	conditions []ConditionalFormatter
+	fallback   Formatter
+}
+
+// Format implements Formatter interface
+func (f *chainFormatter) Format(w http.ResponseWriter, r *http.Request, err HTTPError) {
+	r, err = normalize(r, err)
+	for _, condition := range f.conditions {
+		if condition.matches(r, err) {
+			OrDefault(condition.Then).Format(w, r, err)
+			return
+		}
+	}
+	OrDefault(f.fallback).Format(w, r, err)
+}
+
+// Warmup warms every formatter in the chain
+func (f *chainFormatter) Warmup() error {
+	var errs []error
+	for i, condition := range f.conditions {
+		if condition.Then == nil {
+			continue
+		}
+		if err := warmFormatter(condition.Then); err != nil {
+			errs = append(errs, fmt.Errorf("condition %d: %w", i, err))
+		}
+	}
+	if f.fallback != nil {
+		if err := warmFormatter(f.fallback); err != nil {
+			errs = append(errs, fmt.Errorf("fallback: %w", err))
+		}
+	}
+	return errors.Join(errs...)
+}
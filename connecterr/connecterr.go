@@ -0,0 +1,144 @@
+// Package connecterr translates between connect-go errors and httperrorfmt
+// HTTPErrors, so REST and RPC endpoints share one error surface
+package connecterr
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/perbu/httperrorfmt"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// FromError converts a *connect.Error to an HTTPError. The code becomes the
+// status, an ErrorInfo detail the error code, BadRequest field violations the
+// causes and RetryInfo the Retry-After header. Messages of internal, unknown
+// and data loss errors aren't meant for clients and are replaced with the
+// status text. Errors that are no connect errors give nil.
+func FromError(err error) httperrorfmt.HTTPError {
+	var ce *connect.Error
+	if !errors.As(err, &ce) {
+		return nil
+	}
+	status := httperrorfmt.FromGRPCCode(uint32(ce.Code()))
+	message := ce.Message()
+	switch ce.Code() {
+	case connect.CodeInternal, connect.CodeUnknown, connect.CodeDataLoss:
+		message = ""
+	}
+	if message == "" {
+		message = http.StatusText(status)
+	}
+
+	e := httperrorfmt.Wrap(err, status, message)
+	for _, detail := range ce.Details() {
+		value, derr := detail.Value()
+		if derr != nil {
+			continue
+		}
+		switch d := value.(type) {
+		case *errdetails.ErrorInfo:
+			e.WithCode(d.GetReason())
+		case *errdetails.BadRequest:
+			for _, v := range d.GetFieldViolations() {
+				e.WithCauses(httperrorfmt.Cause{Reason: v.GetReason(), Message: v.GetDescription(), Field: v.GetField()})
+			}
+		case *errdetails.RetryInfo:
+			e.WithRetryAfter(d.GetRetryDelay().AsDuration())
+		}
+	}
+	return e
+}
+
+// Mapping is an httperrorfmt.Mapping for connect errors, for handlers that
+// call connect clients:
+//
+//	mapper.Add(connecterr.Mapping)
+func Mapping(err error) httperrorfmt.HTTPError { return FromError(err) }
+
+// ToError converts an HTTPError to a *connect.Error with the code matching
+// its status and the public message. The error code, causes and Retry-After
+// are attached as ErrorInfo, BadRequest and RetryInfo details.
+func ToError(err httperrorfmt.HTTPError) *connect.Error {
+	ce := connect.NewError(connect.Code(httperrorfmt.ToGRPCCode(err.StatusCode())), errors.New(publicMessage(err)))
+
+	var c interface{ ErrorCode() string }
+	if errors.As(err, &c) && c.ErrorCode() != "" {
+		addDetail(ce, &errdetails.ErrorInfo{Reason: c.ErrorCode()})
+	}
+	var causes interface{ Causes() []httperrorfmt.Cause }
+	if errors.As(err, &causes) && len(causes.Causes()) > 0 {
+		br := &errdetails.BadRequest{}
+		for _, cause := range causes.Causes() {
+			br.FieldViolations = append(br.FieldViolations, &errdetails.BadRequest_FieldViolation{
+				Field: cause.Field, Description: cause.Message, Reason: cause.Reason,
+			})
+		}
+		addDetail(ce, br)
+	}
+	header := make(http.Header)
+	for key, value := range err.Headers() {
+		header.Set(key, value)
+	}
+	if delay, ok := httperrorfmt.RetryAfter(header); ok {
+		addDetail(ce, &errdetails.RetryInfo{RetryDelay: durationpb.New(delay.Round(time.Second))})
+	}
+	return ce
+}
+
+// Interceptor returns a handler interceptor that converts the HTTPErrors
+// handlers return into connect errors with ToError, so business logic shared
+// with REST handlers reports proper RPC codes. Connect errors and other
+// errors pass through unchanged.
+func Interceptor() connect.Interceptor { return interceptor{} }
+
+// interceptor converts the errors of unary and streaming handlers
+type interceptor struct{}
+
+func (interceptor) WrapUnary(next connect.UnaryFunc) connect.UnaryFunc {
+	return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		resp, err := next(ctx, req)
+		return resp, convert(err)
+	}
+}
+
+func (interceptor) WrapStreamingClient(next connect.StreamingClientFunc) connect.StreamingClientFunc {
+	return next
+}
+
+func (interceptor) WrapStreamingHandler(next connect.StreamingHandlerFunc) connect.StreamingHandlerFunc {
+	return func(ctx context.Context, conn connect.StreamingHandlerConn) error {
+		return convert(next(ctx, conn))
+	}
+}
+
+// convert turns an HTTPError into a connect error
+func convert(err error) error {
+	var ce *connect.Error
+	var httpErr httperrorfmt.HTTPError
+	if err == nil || errors.As(err, &ce) || !errors.As(err, &httpErr) {
+		return err
+	}
+	return ToError(httpErr)
+}
+
+// addDetail attaches a detail message to a connect error
+func addDetail(ce *connect.Error, msg proto.Message) {
+	if detail, err := connect.NewErrorDetail(msg); err == nil {
+		ce.AddDetail(detail)
+	}
+}
+
+// publicMessage returns the message of an error that is safe to show clients
+func publicMessage(err httperrorfmt.HTTPError) string {
+	var p interface{ PublicMessage() string }
+	if errors.As(err, &p) {
+		return p.PublicMessage()
+	}
+	return err.Message()
+}
@@ -0,0 +1,12 @@
+module github.com/perbu/httperrorfmt/connecterr
+
+go 1.26.0
+
+replace github.com/perbu/httperrorfmt => ../
+
+require (
+	connectrpc.com/connect v1.21.0
+	github.com/perbu/httperrorfmt v0.0.0-00010101000000-000000000000
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20260921155816-b14227669459
+	google.golang.org/protobuf v1.36.12
+)
@@ -0,0 +1,144 @@
+package httperrorfmt
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Contract declares the error statuses and codes a route may emit. Empty lists
+// allow anything.
+type Contract struct {
+	Statuses []int
+	Codes    []string
+}
+
+// ContractViolation describes an error a route emitted outside its contract
+type ContractViolation struct {
+	Route  string
+	Status int
+	Code   string
+}
+
+// Error implements the error interface
+func (v *ContractViolation) Error() string {
+	if v.Code != "" {
+		return fmt.Sprintf("httperrorfmt: route %q emitted undeclared error %d (%s)", v.Route, v.Status, v.Code)
+	}
+	return fmt.Sprintf("httperrorfmt: route %q emitted undeclared status %d", v.Route, v.Status)
+}
+
+// ContractChecker verifies that routes only emit the errors declared in their
+// contract. Routes without a contract are not checked.
+type ContractChecker struct {
+	Contracts map[string]Contract
+	// Route identifies the route of a request, defaulting to the ServeMux pattern
+	Route func(r *http.Request) string
+	// Assert panics on violations, for use in development and tests
+	Assert bool
+	// OnViolation is called for every violation
+	OnViolation func(r *http.Request, err HTTPError, v *ContractViolation)
+}
+
+// Check reports whether err is allowed by the contract of route. It returns a
+// *ContractViolation when it isn't, which makes it usable directly in tests.
+func (c *ContractChecker) Check(route string, err HTTPError) error {
+	contract, exists := c.Contracts[route]
+	if !exists {
+		return nil
+	}
+	status, code := err.StatusCode(), errorCode(err)
+	if len(contract.Statuses) > 0 && !slices.Contains(contract.Statuses, status) ||
+		len(contract.Codes) > 0 && code != "" && !slices.Contains(contract.Codes, code) {
+		return &ContractViolation{Route: route, Status: status, Code: code}
+	}
+	return nil
+}
+
+// Wrap returns a formatter checking every error against its route's contract
+// before handing it to f
+func (c *ContractChecker) Wrap(f Formatter) Formatter {
+	return &contractFormatter{checker: c, formatter: f}
+}
+
+// contractFormatter checks errors before formatting them
+type contractFormatter struct {
+	checker   *ContractChecker
+	formatter Formatter
+}
+
+// Format implements Formatter interface
+func (f *contractFormatter) Format(w http.ResponseWriter, r *http.Request, err HTTPError) {
+	r, err = normalize(r, err)
+	route := r.Pattern
+	if f.checker.Route != nil {
+		route = f.checker.Route(r)
+	}
+	if cerr := f.checker.Check(route, err); cerr != nil {
+		violation := cerr.(*ContractViolation)
+		if f.checker.OnViolation != nil {
+			f.checker.OnViolation(r, err, violation)
+		}
+		if f.checker.Assert {
+			panic(violation)
+		}
+	}
+	OrDefault(f.formatter).Format(w, r, err)
+}
+
+// ContractsFromOpenAPI builds contracts from the error responses of an OpenAPI 3
+// document in JSON form. Routes are keyed like ServeMux patterns ("GET /users/{id}"),
+// range keys such as "4XX" expand to the whole class, and operations with a
+// "default" response are left unrestricted.
+func ContractsFromOpenAPI(r io.Reader) (map[string]Contract, error) {
+	var doc struct {
+		Paths map[string]map[string]json.RawMessage `json:"paths"`
+	}
+	if err := json.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("httperrorfmt: decoding OpenAPI document: %w", err)
+	}
+
+	contracts := make(map[string]Contract)
+	for path, operations := range doc.Paths {
+		for method, raw := range operations {
+			switch method {
+			case "get", "put", "post", "delete", "options", "head", "patch", "trace":
+			default:
+				// parameters, summary and other path item fields
+				continue
+			}
+			var operation struct {
+				Responses map[string]json.RawMessage `json:"responses"`
+			}
+			if err := json.Unmarshal(raw, &operation); err != nil {
+				return nil, fmt.Errorf("httperrorfmt: %s %s: %w", method, path, err)
+			}
+			if _, unrestricted := operation.Responses["default"]; unrestricted {
+				continue
+			}
+
+			var contract Contract
+			for key := range operation.Responses {
+				if len(key) == 3 && strings.HasSuffix(strings.ToUpper(key), "XX") {
+					class := int(key[0]-'0') * 100
+					for status := class; status < class+100; status++ {
+						contract.Statuses = append(contract.Statuses, status)
+					}
+					continue
+				}
+				status, err := strconv.Atoi(key)
+				if err != nil {
+					return nil, fmt.Errorf("httperrorfmt: %s %s: invalid response key %q", method, path, key)
+				}
+				contract.Statuses = append(contract.Statuses, status)
+			}
+			slices.Sort(contract.Statuses)
+			contracts[strings.ToUpper(method)+" "+path] = contract
+		}
+	}
+	return contracts, nil
+}
@@ -0,0 +1,134 @@
+// Package dberr maps the errors of database/sql and common drivers to
+// httperrorfmt HTTPErrors with messages that are safe to show clients. The
+// driver error is kept for logs only, so table, column and constraint names
+// never reach responses.
+package dberr
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/perbu/httperrorfmt"
+)
+
+// PostgreSQL SQLSTATE codes
+const (
+	pgNotNullViolation    = "23502"
+	pgForeignKeyViolation = "23503"
+	pgUniqueViolation     = "23505"
+	pgCheckViolation      = "23514"
+	pgSerializationFail   = "40001"
+	pgDeadlockDetected    = "40P01"
+	pgQueryCanceled       = "57014"
+)
+
+// MySQL server error numbers
+const (
+	myDuplicateEntry      = 1062
+	myRowIsReferenced     = 1451
+	myNoReferencedRow     = 1452
+	myBadNull             = 1048
+	myCheckViolation      = 3819
+	myLockWaitTimeout     = 1205
+	myDeadlock            = 1213
+	myQueryInterrupted    = 1317
+	myQueryExecutionLimit = 3024
+)
+
+// Mapping is an httperrorfmt.Mapping for database errors:
+//
+//	mapper.Add(dberr.Mapping)
+//
+// sql.ErrNoRows and pgx.ErrNoRows give a 404, unique violations and duplicate
+// entries a 409, foreign key violations a 409, not null and check violations
+// a 400, deadlocks and serialization failures a 503 with Retry-After, and
+// timeouts and cancellations what httperrorfmt.ContextError gives them.
+// Closed or broken connections give a 503. Other errors give nil.
+func Mapping(err error) httperrorfmt.HTTPError {
+	switch {
+	case errors.Is(err, sql.ErrNoRows), errors.Is(err, pgx.ErrNoRows):
+		return httperrorfmt.Wrap(err, http.StatusNotFound, "Not found")
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, context.Canceled):
+		return httperrorfmt.ContextError(err)
+	case errors.Is(err, sql.ErrConnDone), errors.Is(err, driver.ErrBadConn):
+		return unavailable(err)
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return fromSQLState(err, pgErr.Code)
+	}
+	var myErr *mysql.MySQLError
+	if errors.As(err, &myErr) {
+		return fromMySQL(err, myErr.Number)
+	}
+	return nil
+}
+
+// fromSQLState maps a PostgreSQL error by its SQLSTATE code
+func fromSQLState(err error, code string) httperrorfmt.HTTPError {
+	switch code {
+	case pgUniqueViolation:
+		return conflict(err)
+	case pgForeignKeyViolation:
+		return reference(err)
+	case pgNotNullViolation, pgCheckViolation:
+		return invalid(err)
+	case pgSerializationFail, pgDeadlockDetected:
+		return unavailable(err)
+	case pgQueryCanceled:
+		return timeout(err)
+	}
+	return nil
+}
+
+// fromMySQL maps a MySQL error by its error number
+func fromMySQL(err error, number uint16) httperrorfmt.HTTPError {
+	switch number {
+	case myDuplicateEntry:
+		return conflict(err)
+	case myRowIsReferenced, myNoReferencedRow:
+		return reference(err)
+	case myBadNull, myCheckViolation:
+		return invalid(err)
+	case myLockWaitTimeout, myDeadlock:
+		return unavailable(err)
+	case myQueryInterrupted, myQueryExecutionLimit:
+		return timeout(err)
+	}
+	return nil
+}
+
+// conflict is the error for rows that already exist
+func conflict(err error) httperrorfmt.HTTPError {
+	return httperrorfmt.Wrap(err, http.StatusConflict, "The resource already exists").WithCode("ALREADY_EXISTS")
+}
+
+// reference is the error for rows referencing missing rows, or referenced by
+// rows that would be left dangling
+func reference(err error) httperrorfmt.HTTPError {
+	return httperrorfmt.Wrap(err, http.StatusConflict, "The resource conflicts with related resources").WithCode("CONFLICTING_REFERENCE")
+}
+
+// invalid is the error for rows violating a constraint on their values
+func invalid(err error) httperrorfmt.HTTPError {
+	return httperrorfmt.Wrap(err, http.StatusBadRequest, "The request contains invalid values").WithCode("CONSTRAINT_VIOLATION")
+}
+
+// unavailable is the error for transient failures worth retrying
+func unavailable(err error) httperrorfmt.HTTPError {
+	return httperrorfmt.Wrap(err, http.StatusServiceUnavailable, "Service unavailable").WithRetryAfter(time.Second)
+}
+
+// timeout is the error for statements the server canceled, like
+// httperrorfmt.ContextError for exceeded deadlines
+func timeout(err error) httperrorfmt.HTTPError {
+	return httperrorfmt.Wrap(err, http.StatusGatewayTimeout, "The request timed out")
+}
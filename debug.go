@@ -0,0 +1,111 @@
+package httperrorfmt
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+)
+
+// debugState tracks error formatting for one request in debug mode
+type debugState struct {
+	fail func(msg string)
+	// depth counts nested negotiators formatting the same error
+	depth     int
+	formatted HTTPError
+}
+
+// debugKey is the request context key for debugState
+type debugKey struct{}
+
+// debugFrom returns the debug state bound to r, or nil outside debug mode
+func debugFrom(r *http.Request) *debugState {
+	s, _ := r.Context().Value(debugKey{}).(*debugState)
+	return s
+}
+
+// begin records the start of formatting err and reports misuse
+func (s *debugState) begin(err HTTPError) {
+	if err == nil || isNilPointer(err) {
+		s.fail("httperrorfmt: formatting a nil error")
+	}
+	if s.formatted != nil && s.depth == 0 {
+		s.fail(fmt.Sprintf("httperrorfmt: second error formatted for one request: %v (first: %v)", describe(err), describe(s.formatted)))
+	}
+	s.depth++
+}
+
+// end records that formatting err has finished
+func (s *debugState) end(err HTTPError) {
+	s.depth--
+	if s.depth == 0 && s.formatted == nil {
+		s.formatted = err
+	}
+}
+
+// debugWriter reports writes that follow a formatted error
+type debugWriter struct {
+	http.ResponseWriter
+	state *debugState
+}
+
+func (w *debugWriter) WriteHeader(status int) {
+	w.check()
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *debugWriter) Write(p []byte) (int, error) {
+	w.check()
+	return w.ResponseWriter.Write(p)
+}
+
+func (w *debugWriter) check() {
+	if w.state.formatted != nil && w.state.depth == 0 {
+		w.state.fail(fmt.Sprintf("httperrorfmt: response written after error was formatted: %v", describe(w.state.formatted)))
+	}
+}
+
+// Flush forwards to the underlying writer
+func (w *debugWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (w *debugWriter) Unwrap() http.ResponseWriter { return w.ResponseWriter }
+
+// Debug returns middleware that turns formatter misuse into failures: formatting
+// a nil error, formatting two errors for one request and writing to the
+// response after an error was formatted. fail is called with a description of
+// the problem; when nil, Debug panics instead. Pass t.Error to fail tests.
+//
+// Checks run in ContentNegotiator.Format, so errors must be formatted through a
+// negotiator for them to be tracked. Debug is meant for development and tests.
+func Debug(fail func(msg string)) func(http.Handler) http.Handler {
+	if fail == nil {
+		fail = func(msg string) { panic(msg) }
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			state := &debugState{fail: fail}
+			r = r.WithContext(context.WithValue(r.Context(), debugKey{}, state))
+			next.ServeHTTP(&debugWriter{ResponseWriter: w, state: state}, r)
+		})
+	}
+}
+
+// isNilPointer reports whether x, such as an error or a formatter, is a typed
+// nil pointer
+func isNilPointer(x any) bool {
+	v := reflect.ValueOf(x)
+	return v.Kind() == reflect.Pointer && v.IsNil()
+}
+
+// describe renders an error for debug messages without calling methods on nil values
+func describe(err HTTPError) string {
+	if err == nil || isNilPointer(err) {
+		return "<nil>"
+	}
+	return fmt.Sprintf("%d %q", err.StatusCode(), publicMessage(err))
+}
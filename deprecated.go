@@ -0,0 +1,52 @@
+package httperrorfmt
+
+import (
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// DeprecatedFormatter keeps serving a legacy error format while announcing its
+// retirement with Deprecation (RFC 9745) and Sunset (RFC 8594) headers, and
+// counts the remaining usage so the format can be removed safely
+type DeprecatedFormatter struct {
+	Formatter Formatter
+	// Deprecated is when the format was deprecated. Zero leaves the Deprecation header out.
+	Deprecated time.Time
+	// Sunset is when the format goes away. Zero leaves the Sunset header out.
+	Sunset time.Time
+	// Link points to migration documentation, sent as rel="deprecation"
+	Link string
+	// OnUse is called every time the format is served
+	OnUse func(r *http.Request, err HTTPError)
+
+	uses atomic.Int64
+}
+
+// Format implements Formatter interface by adding deprecation headers and
+// delegating to the legacy formatter
+func (f *DeprecatedFormatter) Format(w http.ResponseWriter, r *http.Request, err HTTPError) {
+	r, err = normalize(r, err)
+	f.uses.Add(1)
+	if f.OnUse != nil {
+		f.OnUse(r, err)
+	}
+
+	header := w.Header()
+	if !f.Deprecated.IsZero() {
+		header.Set("Deprecation", "@"+strconv.FormatInt(f.Deprecated.Unix(), 10))
+	}
+	if !f.Sunset.IsZero() {
+		header.Set("Sunset", f.Sunset.UTC().Format(http.TimeFormat))
+	}
+	if f.Link != "" {
+		header.Add("Link", "<"+f.Link+`>; rel="deprecation"; type="text/html"`)
+	}
+	OrDefault(f.Formatter).Format(w, r, err)
+}
+
+// Uses returns how many errors were served in the legacy format
+func (f *DeprecatedFormatter) Uses() int64 {
+	return f.uses.Load()
+}
@@ -0,0 +1,47 @@
+package httperrorfmt
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"hash"
+	"net/http"
+	"strings"
+)
+
+// DigestAlgorithm names a hash algorithm from the HTTP Digest Algorithm registry
+type DigestAlgorithm string
+
+// Supported digest algorithms
+const (
+	DigestSHA256 DigestAlgorithm = "sha-256"
+	DigestSHA512 DigestAlgorithm = "sha-512"
+)
+
+// ReprDigest returns a post-processor adding an RFC 9530 Repr-Digest header over
+// the rendered error body. SHA-256 is used when no algorithm is given;
+// unsupported algorithms are skipped.
+func ReprDigest(algorithms ...DigestAlgorithm) PostProcessor {
+	if len(algorithms) == 0 {
+		algorithms = []DigestAlgorithm{DigestSHA256}
+	}
+	return PostProcessorFunc(func(r *http.Request, err HTTPError, resp *Response) {
+		var members []string
+		for _, algorithm := range algorithms {
+			var h hash.Hash
+			switch algorithm {
+			case DigestSHA256:
+				h = sha256.New()
+			case DigestSHA512:
+				h = sha512.New()
+			default:
+				continue
+			}
+			h.Write(resp.Body)
+			members = append(members, string(algorithm)+"=:"+base64.StdEncoding.EncodeToString(h.Sum(nil))+":")
+		}
+		if len(members) > 0 {
+			resp.Header.Set("Repr-Digest", strings.Join(members, ", "))
+		}
+	})
+}
@@ -0,0 +1,92 @@
+package httperrorfmt
+
+import (
+	"net/http"
+	"net/netip"
+)
+
+// Disclosure is how much detail an error response reveals
+type Disclosure int
+
+const (
+	// DiscloseConfigured renders the details the negotiator's Features enable
+	DiscloseConfigured Disclosure = iota
+	// DiscloseSanitized leaves out stack traces, even those formatters such as
+	// JSONFormatter.IncludeStack add, and scrubs messages with
+	// DefaultRedactors unless the negotiator has a redactor of its own
+	DiscloseSanitized
+	// DiscloseFull adds stack traces, causes, error ids, documentation URLs
+	// and the method and path of the request to the configured details
+	DiscloseFull
+)
+
+// DisclosurePolicy decides, for each error a negotiator formats, how much
+// detail the response reveals
+type DisclosurePolicy interface {
+	Disclosure(r *http.Request, err HTTPError) Disclosure
+}
+
+// DisclosurePolicyFunc adapts an ordinary function to a DisclosurePolicy,
+// e.g. one granting full detail to administrators:
+//
+//	httperrorfmt.DisclosurePolicyFunc(func(r *http.Request, err httperrorfmt.HTTPError) httperrorfmt.Disclosure {
+//		if claims, ok := auth.ClaimsFrom(r.Context()); ok && claims.HasRole("admin") {
+//			return httperrorfmt.DiscloseFull
+//		}
+//		return httperrorfmt.DiscloseSanitized
+//	})
+type DisclosurePolicyFunc func(r *http.Request, err HTTPError) Disclosure
+
+// Disclosure calls f(r, err)
+func (f DisclosurePolicyFunc) Disclosure(r *http.Request, err HTTPError) Disclosure { return f(r, err) }
+
+// InternalNetworks is a policy giving full detail to requests whose peer
+// address is in one of the networks and sanitized output to everyone else.
+// Behind a proxy the peer is the proxy, so list the networks of clients only
+// when they connect directly.
+func InternalNetworks(networks ...netip.Prefix) DisclosurePolicy {
+	return DisclosurePolicyFunc(func(r *http.Request, _ HTTPError) Disclosure {
+		if fromNetworks(r, networks) {
+			return DiscloseFull
+		}
+		return DiscloseSanitized
+	})
+}
+
+// MostDisclosing combines policies, giving each request the most detail any
+// of them allows, e.g. for admins and internal networks alike
+func MostDisclosing(policies ...DisclosurePolicy) DisclosurePolicy {
+	return DisclosurePolicyFunc(func(r *http.Request, err HTTPError) Disclosure {
+		disclosure := DiscloseConfigured
+		for _, policy := range policies {
+			if d := policy.Disclosure(r, err); d == DiscloseFull {
+				return DiscloseFull
+			} else if d == DiscloseSanitized {
+				disclosure = DiscloseSanitized
+			}
+		}
+		return disclosure
+	})
+}
+
+// SetDisclosurePolicy makes the negotiator consult policy for every error it
+// formats, adjusting the configured Features to the disclosure it returns
+func (cn *ContentNegotiator) SetDisclosurePolicy(policy DisclosurePolicy) *ContentNegotiator {
+	cn.disclosure = policy
+	return cn
+}
+
+// disclose adjusts features to a disclosure
+func (s *settings) disclose(disclosure Disclosure) {
+	s.sanitized = disclosure == DiscloseSanitized
+	switch disclosure {
+	case DiscloseSanitized:
+		s.features.Stacks = false
+	case DiscloseFull:
+		s.features.Stacks = true
+		s.features.Causes = true
+		s.features.ErrorIDs = true
+		s.features.DocURLs = true
+		s.features.RequestInfo = true
+	}
+}
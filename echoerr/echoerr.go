@@ -0,0 +1,74 @@
+// Package echoerr renders the errors of echo handlers through httperrorfmt
+package echoerr
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/perbu/httperrorfmt"
+)
+
+// Errors renders the errors of an echo application through one formatter,
+// usually a shared ContentNegotiator
+type Errors struct {
+	// Formatter renders errors. Nil means httperrorfmt.Default.
+	Formatter httperrorfmt.Formatter
+	// Mapper converts errors that are neither HTTPErrors nor echo.HTTPErrors.
+	// Nil turns them into 500s.
+	Mapper *httperrorfmt.ErrorMapper
+}
+
+// New creates an Errors rendering through formatter
+func New(formatter httperrorfmt.Formatter, mapper *httperrorfmt.ErrorMapper) *Errors {
+	return &Errors{Formatter: formatter, Mapper: mapper}
+}
+
+// Handler is an echo.HTTPErrorHandler:
+//
+//	e.HTTPErrorHandler = echoerr.New(negotiator, mapper).Handler
+//
+// Responses that were already committed are left alone.
+func (e *Errors) Handler(err error, c echo.Context) {
+	if c.Response().Committed {
+		return
+	}
+	e.formatter().Format(c.Response(), c.Request(), e.Translate(err, c))
+}
+
+// Translate converts err to an HTTPError. HTTPErrors are kept, echo.HTTPErrors
+// keep their status and string message, and other errors go through Mapper.
+// The 405s of echo's router list the methods of the Allow header it set.
+func (e *Errors) Translate(err error, c echo.Context) httperrorfmt.HTTPError {
+	var httpErr httperrorfmt.HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr
+	}
+	var he *echo.HTTPError
+	if !errors.As(err, &he) {
+		return e.Mapper.Map(err)
+	}
+	if inner, ok := he.Internal.(*echo.HTTPError); ok {
+		he = inner
+	}
+
+	if he.Code == http.StatusMethodNotAllowed {
+		var methods []string
+		for _, value := range c.Response().Header().Values(echo.HeaderAllow) {
+			methods = append(methods, strings.Split(value, ",")...)
+		}
+		return httperrorfmt.MethodNotAllowed(methods...)
+	}
+	// Only string messages are meant for clients; errors may carry internals
+	message, ok := he.Message.(string)
+	if !ok || message == "" {
+		message = http.StatusText(he.Code)
+	}
+	return httperrorfmt.Wrap(err, he.Code, message)
+}
+
+// formatter returns Formatter or the package default
+func (e *Errors) formatter() httperrorfmt.Formatter {
+	return httperrorfmt.OrDefault(e.Formatter)
+}
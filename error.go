@@ -0,0 +1,160 @@
+package httperrorfmt
+
+import (
+	"errors"
+	"net/http"
+)
+
+// Error is a ready-made HTTPError implementation
+type Error struct {
+	status   int
+	public   string
+	internal string
+	code     string
+	headers  map[string]string
+	err      error
+
+	translations map[string]string
+	extensions   map[string]any
+	rateLimit    *RateLimitInfo
+	challenges   []Challenge
+	allowed      []string
+	causes       []Cause
+	codeInternal bool
+	transient    *bool
+
+	classification Classification
+	retryHints     RetryHints
+	remediation    *Remediation
+}
+
+// New creates an error with a status code and a message that is safe to show clients
+func New(status int, message string) *Error {
+	return &Error{
+		status:  status,
+		public:  message,
+		headers: make(map[string]string),
+	}
+}
+
+// Wrap creates an error around err. The wrapped error only surfaces in the
+// internal message, never in responses.
+func Wrap(err error, status int, message string) *Error {
+	e := New(status, message)
+	e.err = err
+	return e
+}
+
+// WithInternal sets the message meant for logs and debugging
+func (e *Error) WithInternal(message string) *Error {
+	e.internal = message
+	return e
+}
+
+// WithCode sets the application specific error code
+func (e *Error) WithCode(code string) *Error {
+	e.code = code
+	return e
+}
+
+// WithHeader adds a header to send with the error response
+func (e *Error) WithHeader(key, value string) *Error {
+	e.headers[http.CanonicalHeaderKey(key)] = value
+	return e
+}
+
+// WithTranslation adds the public message in another language. The translation
+// matching the request's Accept-Language header is sent instead of the message.
+func (e *Error) WithTranslation(locale, message string) *Error {
+	if e.translations == nil {
+		e.translations = make(map[string]string)
+	}
+	e.translations[locale] = message
+	return e
+}
+
+// WithExtensions adds problem extension members, rendered by ProblemFormatter
+// and by JSONFormatter shapes with Extensions enabled. Extensions without a
+// name, such as the zero Extension, are ignored.
+func (e *Error) WithExtensions(extensions ...Extension) *Error {
+	for _, extension := range extensions {
+		if extension.name == "" {
+			continue
+		}
+		if e.extensions == nil {
+			e.extensions = make(map[string]any)
+		}
+		e.extensions[extension.name] = extension.value
+	}
+	return e
+}
+
+// WithCauses adds causes, such as the fields that failed validation
+func (e *Error) WithCauses(causes ...Cause) *Error {
+	e.causes = append(e.causes, causes...)
+	return e
+}
+
+// Error implements the error interface using the internal message
+func (e *Error) Error() string { return e.InternalMessage() }
+
+// StatusCode returns the HTTP status code
+func (e *Error) StatusCode() int { return e.status }
+
+// Message returns the public message
+func (e *Error) Message() string { return e.public }
+
+// Headers returns the headers to send with the error response
+func (e *Error) Headers() map[string]string { return e.headers }
+
+// PublicMessage returns the message that is safe to send to clients
+func (e *Error) PublicMessage() string { return e.public }
+
+// InternalMessage returns the message meant for logs and debugging, falling back
+// to the wrapped error and then the public message
+func (e *Error) InternalMessage() string {
+	switch {
+	case e.internal != "":
+		return e.internal
+	case e.err != nil:
+		return e.err.Error()
+	default:
+		return e.public
+	}
+}
+
+// Translations returns the public message by locale
+func (e *Error) Translations() map[string]string { return e.translations }
+
+// Extensions returns the problem extension members
+func (e *Error) Extensions() map[string]any { return e.extensions }
+
+// ErrorCode returns the application specific error code, unless it is an
+// internal code that isn't sent to clients
+func (e *Error) ErrorCode() string {
+	if e.codeInternal {
+		return ""
+	}
+	return e.code
+}
+
+// InternalErrorCode returns the application specific error code, including
+// internal codes
+func (e *Error) InternalErrorCode() string { return e.code }
+
+// Causes returns the causes added with WithCauses, or else those of the
+// wrapped error
+func (e *Error) Causes() []Cause {
+	var c interface{ Causes() []Cause }
+	if len(e.causes) == 0 && errors.As(e.err, &c) {
+		return c.Causes()
+	}
+	return e.causes
+}
+
+// Unwrap returns the wrapped error
+func (e *Error) Unwrap() error { return e.err }
+
+// errorBase lets other error types embed Error without the field name
+// shadowing the Error method
+type errorBase = Error
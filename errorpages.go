@@ -0,0 +1,275 @@
+package httperrorfmt
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// ErrorPage describes how an edge proxy rendered errors with a given status
+type ErrorPage struct {
+	Status         int    // status the rule matches
+	Target         string // page URI (nginx) or file name (Envoy), if any
+	Body           string // inline body (Envoy inline_string), if any
+	ResponseStatus int    // status sent instead of Status, 0 keeps it
+}
+
+// ErrorPageTable maps status codes to the error page configured for them
+type ErrorPageTable map[int]ErrorPage
+
+// ParseNginxErrorPages reads error_page directives from an nginx config snippet.
+// Directives are collected regardless of the server or location block they appear in.
+func ParseNginxErrorPages(r io.Reader) (ErrorPageTable, error) {
+	var config strings.Builder
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		config.WriteString(line)
+		config.WriteByte('\n')
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	table := make(ErrorPageTable)
+	for _, statement := range strings.Split(config.String(), ";") {
+		fields := strings.Fields(strings.NewReplacer("{", " ", "}", " ").Replace(statement))
+		idx := -1
+		for i, field := range fields {
+			if field == "error_page" {
+				idx = i
+				break
+			}
+		}
+		if idx < 0 {
+			continue
+		}
+		args := fields[idx+1:]
+		if len(args) < 2 {
+			return nil, fmt.Errorf("httperrorfmt: malformed error_page directive %q", strings.TrimSpace(statement))
+		}
+
+		page := ErrorPage{Target: strings.Trim(args[len(args)-1], `"'`)}
+		var codes []int
+		for _, arg := range args[:len(args)-1] {
+			if strings.HasPrefix(arg, "=") {
+				// A bare "=" takes the status from the page itself, which we can't know
+				if arg != "=" {
+					status, err := strconv.Atoi(arg[1:])
+					if err != nil {
+						return nil, fmt.Errorf("httperrorfmt: invalid error_page response code %q", arg)
+					}
+					page.ResponseStatus = status
+				}
+				continue
+			}
+			status, err := strconv.Atoi(arg)
+			if err != nil {
+				return nil, fmt.Errorf("httperrorfmt: invalid error_page status %q", arg)
+			}
+			codes = append(codes, status)
+		}
+		for _, status := range codes {
+			page.Status = status
+			table[status] = page
+		}
+	}
+	return table, nil
+}
+
+// envoyDataSource mirrors Envoy's config.core.v3.DataSource
+type envoyDataSource struct {
+	Filename     string `json:"filename"`
+	InlineString string `json:"inline_string"`
+	InlineBytes  string `json:"inline_bytes"`
+}
+
+// envoyLocalReplyConfig mirrors the parts of Envoy's LocalReplyConfig we understand
+type envoyLocalReplyConfig struct {
+	Mappers []struct {
+		Filter map[string]json.RawMessage `json:"filter"`
+		// StatusCode is a wrapped uint32 in Envoy and appears as a plain number in JSON
+		StatusCode int              `json:"status_code"`
+		Body       *envoyDataSource `json:"body"`
+	} `json:"mappers"`
+}
+
+// ParseEnvoyLocalReply reads an Envoy local_reply_config in its JSON form, either on
+// its own or embedded in an HttpConnectionManager config. Only status_code_filter
+// mappers can be expressed as per-status rules; other filters are reported as errors.
+// YAML configs need converting to JSON first.
+func ParseEnvoyLocalReply(r io.Reader) (ErrorPageTable, error) {
+	var doc struct {
+		envoyLocalReplyConfig
+		LocalReplyConfig *envoyLocalReplyConfig `json:"local_reply_config"`
+	}
+	if err := json.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("httperrorfmt: decoding envoy config: %w", err)
+	}
+	config := &doc.envoyLocalReplyConfig
+	if doc.LocalReplyConfig != nil {
+		config = doc.LocalReplyConfig
+	}
+
+	table := make(ErrorPageTable)
+	for i, mapper := range config.Mappers {
+		raw, ok := mapper.Filter["status_code_filter"]
+		if !ok || len(mapper.Filter) != 1 {
+			return nil, fmt.Errorf("httperrorfmt: envoy mapper %d: only status_code_filter is supported", i)
+		}
+		var filter struct {
+			Comparison struct {
+				Op    string `json:"op"`
+				Value struct {
+					DefaultValue int `json:"default_value"`
+				} `json:"value"`
+			} `json:"comparison"`
+		}
+		if err := json.Unmarshal(raw, &filter); err != nil {
+			return nil, fmt.Errorf("httperrorfmt: envoy mapper %d: %w", i, err)
+		}
+
+		value := filter.Comparison.Value.DefaultValue
+		low, high := value, value
+		switch filter.Comparison.Op {
+		case "", "EQ":
+		case "GE":
+			high = 599
+		case "LE":
+			low = 100
+		default:
+			return nil, fmt.Errorf("httperrorfmt: envoy mapper %d: unsupported comparison %q", i, filter.Comparison.Op)
+		}
+
+		page := ErrorPage{ResponseStatus: mapper.StatusCode}
+		if body := mapper.Body; body != nil {
+			page.Target = body.Filename
+			page.Body = body.InlineString
+			if body.InlineBytes != "" {
+				decoded, err := base64.StdEncoding.DecodeString(body.InlineBytes)
+				if err != nil {
+					return nil, fmt.Errorf("httperrorfmt: envoy mapper %d: %w", i, err)
+				}
+				page.Body = string(decoded)
+			}
+		}
+
+		for status := low; status <= high; status++ {
+			// Envoy uses the first matching mapper
+			if _, exists := table[status]; exists {
+				continue
+			}
+			page.Status = status
+			table[status] = page
+		}
+	}
+	return table, nil
+}
+
+// Formatter builds a StatusFormatter from the table, using resolve to turn each page
+// into a formatter. Statuses without a page are rendered by fallback.
+func (t ErrorPageTable) Formatter(resolve func(ErrorPage) (Formatter, error), fallback Formatter) (*StatusFormatter, error) {
+	sf := &StatusFormatter{
+		Formatters: make(map[int]Formatter, len(t)),
+		Default:    fallback,
+	}
+	for status, page := range t {
+		formatter, err := resolve(page)
+		if err != nil {
+			return nil, fmt.Errorf("httperrorfmt: error page for %d: %w", status, err)
+		}
+		if page.ResponseStatus != 0 && page.ResponseStatus != status {
+			formatter = &statusRewriter{formatter: formatter, status: page.ResponseStatus}
+		}
+		sf.Formatters[status] = formatter
+	}
+	return sf, nil
+}
+
+// PagesFromFS returns a resolver serving error page targets from fsys. Inline
+// bodies are served as plain text.
+func PagesFromFS(fsys fs.FS) func(ErrorPage) (Formatter, error) {
+	return func(page ErrorPage) (Formatter, error) {
+		if page.Target == "" || strings.HasPrefix(page.Target, "@") {
+			if page.Body == "" {
+				return nil, fmt.Errorf("page %q can't be served statically", page.Target)
+			}
+			return &StaticFormatter{ContentType: "text/plain", Body: []byte(page.Body)}, nil
+		}
+		body, err := fs.ReadFile(fsys, strings.TrimPrefix(path.Clean(page.Target), "/"))
+		if err != nil {
+			return nil, err
+		}
+		contentType := mime.TypeByExtension(path.Ext(page.Target))
+		if contentType == "" {
+			contentType = http.DetectContentType(body)
+		}
+		return &StaticFormatter{ContentType: contentType, Body: body}, nil
+	}
+}
+
+// StatusFormatter routes errors to formatters by status code
+type StatusFormatter struct {
+	Formatters map[int]Formatter
+	Default    Formatter
+}
+
+// Format implements Formatter interface by dispatching on the error status
+func (f *StatusFormatter) Format(w http.ResponseWriter, r *http.Request, err HTTPError) {
+	r, err = normalize(r, err)
+	if formatter, exists := f.Formatters[err.StatusCode()]; exists {
+		formatter.Format(w, r, err)
+		return
+	}
+	OrDefault(f.Default).Format(w, r, err)
+}
+
+// StaticFormatter writes a fixed body for every error
+type StaticFormatter struct {
+	ContentType string
+	Body        []byte
+}
+
+// Format implements Formatter interface for static error pages
+func (f *StaticFormatter) Format(w http.ResponseWriter, r *http.Request, err HTTPError) {
+	r, err = normalize(r, err)
+	writeHeader(w, r, err, f.ContentType)
+	w.Write(f.Body)
+}
+
+// statusRewriter renders errors through a formatter with a different status
+type statusRewriter struct {
+	formatter Formatter
+	status    int
+}
+
+// Format implements Formatter interface
+func (f *statusRewriter) Format(w http.ResponseWriter, r *http.Request, err HTTPError) {
+	r, err = normalize(r, err)
+	f.formatter.Format(w, r, withStatus(err, f.status))
+}
+
+// statusError overrides the status code of a wrapped error
+type statusError struct {
+	HTTPError
+	status int
+}
+
+// withStatus returns err reporting the given status code
+func withStatus(err HTTPError, status int) HTTPError {
+	return &statusError{HTTPError: err, status: status}
+}
+
+// StatusCode returns the overridden status code
+func (e *statusError) StatusCode() int { return e.status }
+
+// Unwrap returns the original error
+func (e *statusError) Unwrap() error { return e.HTTPError }
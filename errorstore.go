@@ -0,0 +1,229 @@
+package httperrorfmt
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// StoredError is the full record of a server error, kept for support staff
+// while clients only see its id
+type StoredError struct {
+	ID       string          `json:"id"`
+	Time     time.Time       `json:"time"`
+	Status   int             `json:"status"`
+	Message  string          `json:"message"`
+	Internal string          `json:"internal,omitempty"`
+	Code     string          `json:"code,omitempty"`
+	Stack    string          `json:"stack,omitempty"`
+	Request  RequestSnapshot `json:"request"`
+}
+
+// RequestSnapshot is the part of a request worth keeping with a stored error.
+// Credentials are never included.
+type RequestSnapshot struct {
+	Method     string      `json:"method"`
+	URL        string      `json:"url"`
+	RemoteAddr string      `json:"remote_addr,omitempty"`
+	Header     http.Header `json:"header,omitempty"`
+}
+
+// sensitiveHeaders are request headers left out of snapshots
+var sensitiveHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "X-Api-Key"}
+
+// snapshot records the parts of r worth keeping
+func snapshot(r *http.Request) RequestSnapshot {
+	header := r.Header.Clone()
+	for _, name := range sensitiveHeaders {
+		header.Del(name)
+	}
+	s := RequestSnapshot{Method: r.Method, RemoteAddr: r.RemoteAddr, Header: header}
+	if r.URL != nil {
+		s.URL = r.URL.String()
+	}
+	return s
+}
+
+// ErrNotStored is returned by ErrorStore.Load for unknown ids
+var ErrNotStored = errors.New("httperrorfmt: error not stored")
+
+// ErrorStore keeps server errors by id. Implementations must be safe for
+// concurrent use.
+type ErrorStore interface {
+	Save(ctx context.Context, e StoredError) error
+	// Load returns the error stored under id, or ErrNotStored
+	Load(ctx context.Context, id string) (StoredError, error)
+}
+
+// SetErrorStore makes the negotiator save every 5xx it formats to store. The
+// response carries the error id and nothing of the stored details beyond what
+// the features enable. Failures to save are reported to MisuseHandler.
+func (cn *ContentNegotiator) SetErrorStore(store ErrorStore) *ContentNegotiator {
+	cn.store = store
+	return cn
+}
+
+// storedError marks an error saved to a store, carrying its id
+type storedError struct {
+	HTTPError
+	id string
+}
+
+// ErrorID returns the id the error was stored under
+func (e *storedError) ErrorID() string { return e.id }
+
+// Unwrap returns the original error
+func (e *storedError) Unwrap() error { return e.HTTPError }
+
+// storeError saves err and returns it marked with its id. Errors already
+// stored by an enclosing negotiator are returned unchanged.
+func storeError(r *http.Request, err HTTPError, store ErrorStore, ids IDGenerator) HTTPError {
+	var stored *storedError
+	if errors.As(err, &stored) {
+		return err
+	}
+	record := StoredError{
+		ID:       errorID(err, ids),
+		Time:     time.Now().UTC(),
+		Status:   err.StatusCode(),
+		Message:  publicMessage(err),
+		Internal: internalMessage(err),
+		Code:     internalErrorCode(err),
+		Stack:    stackOf(err),
+		Request:  snapshot(r),
+	}
+	if serr := store.Save(r.Context(), record); serr != nil {
+		reportMisuse(r, fmt.Errorf("httperrorfmt: storing error %s: %w", record.ID, serr))
+	}
+	return &storedError{HTTPError: err, id: record.ID}
+}
+
+// MemoryStore is an ErrorStore keeping the most recent errors in memory
+type MemoryStore struct {
+	mu       sync.Mutex
+	capacity int
+	order    *list.List
+	entries  map[string]*list.Element
+}
+
+// NewMemoryStore creates a store holding up to capacity errors, evicting the
+// least recently used
+func NewMemoryStore(capacity int) *MemoryStore {
+	return &MemoryStore{
+		capacity: max(capacity, 1),
+		order:    list.New(),
+		entries:  make(map[string]*list.Element),
+	}
+}
+
+// Save implements ErrorStore
+func (s *MemoryStore) Save(_ context.Context, e StoredError) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if elem, ok := s.entries[e.ID]; ok {
+		elem.Value = e
+		s.order.MoveToFront(elem)
+		return nil
+	}
+	s.entries[e.ID] = s.order.PushFront(e)
+	for s.order.Len() > s.capacity {
+		oldest := s.order.Back()
+		s.order.Remove(oldest)
+		delete(s.entries, oldest.Value.(StoredError).ID)
+	}
+	return nil
+}
+
+// Load implements ErrorStore
+func (s *MemoryStore) Load(_ context.Context, id string) (StoredError, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	elem, ok := s.entries[id]
+	if !ok {
+		return StoredError{}, ErrNotStored
+	}
+	s.order.MoveToFront(elem)
+	return elem.Value.(StoredError), nil
+}
+
+// FileStore is an ErrorStore writing one JSON file per error to a directory.
+// Old files are never removed; rotate them with the usual tools.
+type FileStore struct {
+	Dir string
+}
+
+// Save implements ErrorStore
+func (s *FileStore) Save(_ context.Context, e StoredError) error {
+	path, err := s.path(e.ID)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(e, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o600)
+}
+
+// Load implements ErrorStore
+func (s *FileStore) Load(_ context.Context, id string) (StoredError, error) {
+	path, err := s.path(id)
+	if err != nil {
+		return StoredError{}, ErrNotStored
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return StoredError{}, ErrNotStored
+	}
+	if err != nil {
+		return StoredError{}, err
+	}
+	var e StoredError
+	err = json.Unmarshal(data, &e)
+	return e, err
+}
+
+// path returns the file of an id, refusing ids that could escape the directory
+func (s *FileStore) path(id string) (string, error) {
+	if id == "" || strings.IndexFunc(id, func(c rune) bool {
+		return !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_')
+	}) >= 0 {
+		return "", fmt.Errorf("httperrorfmt: invalid error id %q", id)
+	}
+	return filepath.Join(s.Dir, id+".json"), nil
+}
+
+// ErrorStoreHandler serves stored errors as JSON for support tooling. The id
+// is the {id} path wildcard when routed with one, otherwise the last path
+// segment. The handler exposes internal details: protect it like any admin
+// endpoint.
+func ErrorStoreHandler(store ErrorStore) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.PathValue("id")
+		if id == "" {
+			id = r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+		}
+		formatter := &JSONFormatter{}
+		e, err := store.Load(r.Context(), id)
+		switch {
+		case errors.Is(err, ErrNotStored):
+			formatter.Format(w, r, New(http.StatusNotFound, "No error stored with this id"))
+			return
+		case err != nil:
+			formatter.Format(w, r, Wrap(err, http.StatusInternalServerError, "The error store failed"))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.Header().Set("Cache-Control", "no-store")
+		data, _ := json.MarshalIndent(e, "", "  ")
+		w.Write(data)
+	})
+}
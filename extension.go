@@ -0,0 +1,74 @@
+package httperrorfmt
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// reservedMembers are member names extensions can't use: those defined by
+// RFC 9457 and those the formatters add themselves
+var reservedMembers = append(slices.Clone(problemMembers),
+	"items", "error_code", "error_id", "timestamp", "request_id", "retry_after", "retryable", "idempotency_safe", "rate_limit", "allowed_methods", "blocked_by", "method", "path", "help_url", "remediation", "causes", "category", "stack")
+
+// ValidExtensionName checks a problem extension member name. RFC 9457
+// recommends names of at least three characters, starting with a letter and
+// made of letters, digits and underscores. Reserved names are rejected.
+func ValidExtensionName(name string) error {
+	if len(name) < 3 {
+		return fmt.Errorf("httperrorfmt: extension name %q is shorter than three characters", name)
+	}
+	for i, c := range name {
+		letter := c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+		if i == 0 && !letter || !letter && !(c >= '0' && c <= '9') && c != '_' {
+			return fmt.Errorf("httperrorfmt: extension name %q must start with a letter and contain only letters, digits and underscores", name)
+		}
+	}
+	if slices.Contains(reservedMembers, name) {
+		return fmt.Errorf("httperrorfmt: extension name %q is reserved", name)
+	}
+	return nil
+}
+
+// ExtensionKey names a problem extension member holding values of type T.
+// Declare keys once at package level:
+//
+//	var Balance = httperrorfmt.NewExtensionKey[int]("balance")
+type ExtensionKey[T any] struct {
+	name string
+}
+
+// NewExtensionKey creates a key for the member name. It panics if the name is
+// not valid according to ValidExtensionName.
+func NewExtensionKey[T any](name string) ExtensionKey[T] {
+	if err := ValidExtensionName(name); err != nil {
+		panic(err)
+	}
+	return ExtensionKey[T]{name: name}
+}
+
+// Name returns the member name
+func (k ExtensionKey[T]) Name() string { return k.name }
+
+// Value pairs the key with a value, for Error.WithExtensions
+func (k ExtensionKey[T]) Value(value T) Extension {
+	return Extension{name: k.name, value: value}
+}
+
+// Extension is a problem extension member created with ExtensionKey.Value
+type Extension struct {
+	name  string
+	value any
+}
+
+// ExtensionOf returns the value of an extension member of err, if it is set
+// and has the key's type
+func ExtensionOf[T any](err error, key ExtensionKey[T]) (T, bool) {
+	var e interface{ Extensions() map[string]any }
+	if errors.As(err, &e) {
+		value, ok := e.Extensions()[key.name].(T)
+		return value, ok
+	}
+	var zero T
+	return zero, false
+}
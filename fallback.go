@@ -0,0 +1,92 @@
+package httperrorfmt
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// FallibleFormatter is a Formatter that can report that it failed, e.g.
+// because its template broke. FallbackFormatter moves on to the next formatter
+// when TryFormat returns an error.
+type FallibleFormatter interface {
+	Formatter
+	TryFormat(w http.ResponseWriter, r *http.Request, err HTTPError) error
+}
+
+// FallbackFormatter tries formatters in order until one renders a response.
+// A formatter fails when it panics, returns an error from TryFormat, writes
+// nothing or writes a non-error status for an error. When all fail, a minimal
+// plain-text response is written, so an error never turns into an empty 200.
+type FallbackFormatter struct {
+	Formatters []Formatter
+	// OnFailure is called for every formatter that failed
+	OnFailure func(r *http.Request, err HTTPError, failure error)
+}
+
+// Format implements Formatter interface
+func (f *FallbackFormatter) Format(w http.ResponseWriter, r *http.Request, err HTTPError) {
+	r, err = normalize(r, err)
+	for i, formatter := range f.Formatters {
+		resp, failure := tryFormat(formatter, w.Header(), r, err)
+		if failure == nil {
+			writeResponse(w, resp)
+			return
+		}
+		if f.OnFailure != nil {
+			f.OnFailure(r, err, fmt.Errorf("formatter %d (%T): %w", i, formatter, failure))
+		}
+	}
+	writeMinimal(w, err)
+}
+
+// tryFormat renders err through formatter into memory and reports why the
+// result can't be used, if it can't
+func tryFormat(formatter Formatter, header http.Header, r *http.Request, err HTTPError) (resp *Response, failure error) {
+	defer func() {
+		if v := recover(); v != nil {
+			if v == http.ErrAbortHandler {
+				panic(v)
+			}
+			resp, failure = nil, fmt.Errorf("panic: %v", v)
+		}
+	}()
+
+	buffer := newResponseBuffer(header)
+	if fallible, ok := formatter.(FallibleFormatter); ok {
+		if failure := fallible.TryFormat(buffer, r, err); failure != nil {
+			return nil, failure
+		}
+	} else if formatter != nil {
+		formatter.Format(buffer, r, err)
+	} else {
+		return nil, fmt.Errorf("nil formatter")
+	}
+
+	switch {
+	case buffer.status == 0 && buffer.body.Len() == 0:
+		return nil, fmt.Errorf("nothing written")
+	case buffer.status < 400 && err.StatusCode() >= 400:
+		return nil, fmt.Errorf("wrote status %d for a %d error", buffer.response().Status, err.StatusCode())
+	}
+	return buffer.response(), nil
+}
+
+// writeMinimal writes the status line and status text as plain text, touching
+// as little of err as possible
+func writeMinimal(w http.ResponseWriter, err HTTPError) {
+	status := http.StatusInternalServerError
+	func() {
+		defer func() { recover() }()
+		if s := err.StatusCode(); s >= 400 && s <= 599 {
+			status = s
+		}
+	}()
+
+	header := w.Header()
+	header.Del("Content-Length")
+	header.Del("Content-Encoding")
+	header.Set("Content-Type", "text/plain; charset=utf-8")
+	header.Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	fmt.Fprintf(w, "%d %s\n", status, statusText(status))
+}
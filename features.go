@@ -0,0 +1,224 @@
+package httperrorfmt
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+)
+
+// Features toggles optional response content. Set them once on a ContentNegotiator
+// and every formatter it dispatches to renders them. Formatters for fixed
+// third-party envelopes (Google, Kubernetes, SCIM, OAuth, Twirp, HAL, AWS,
+// OData and SOAP) have no room for most of them and ignore them.
+type Features struct {
+	// Stacks includes the stack trace of errors implementing Stack() string
+	Stacks bool
+	// Timestamps includes the time the error was formatted
+	Timestamps bool
+	// TimeFormat is the layout of timestamps. Empty means time.RFC3339.
+	TimeFormat string
+	// TimeZone is the zone timestamps are written in. Nil means UTC.
+	TimeZone *time.Location
+	// Now returns the time errors are formatted at. Nil means time.Now.
+	Now func() time.Time
+	// RetryAfter includes the delay of errors with a Retry-After header, or
+	// implementing RetryAfter() time.Duration, in seconds. Errors with rate
+	// limit information always include it.
+	RetryAfter bool
+	// RequestInfo includes the method and path of the request, without the query
+	RequestInfo bool
+	// ErrorIDs includes the id of errors implementing ErrorID() string, or a generated one
+	ErrorIDs bool
+	// DocURLs includes the documentation URL of errors implementing DocURL() string
+	DocURLs bool
+	// Docs finds the documentation URL of errors without their own
+	Docs *DocsLinkResolver
+	// Registry provides titles and documentation URLs for registered codes
+	Registry *Registry
+	// Localizer formats the numbers, dates and durations of HTML templates and
+	// the retry hint of plain text in the language of the response
+	Localizer Localizer
+	// HelpLinks sends the documentation URL as a Link header with rel="help"
+	HelpLinks bool
+	// Causes includes the causes of errors implementing Causes() []Cause
+	Causes bool
+	// Categories includes the classification of errors, see ClassificationOf
+	Categories bool
+	// IDs generates the ids of errors without their own. Nil means UUIDv7.
+	IDs IDGenerator
+	// RequestID includes the correlation id of the request in bodies and echoes
+	// it as a response header
+	RequestID *RequestIDExtractor
+}
+
+// settings carries negotiator wide configuration to formatters
+type settings struct {
+	features Features
+	tracing  bool
+	// sanitized suppresses details formatters add on their own, such as stacks
+	sanitized bool
+	// errorID is the id generated once for the error of the request, so the
+	// body, hooks and reports agree
+	errorID string
+}
+
+// settingsKey is the request context key for settings
+type settingsKey struct{}
+
+// withSettings returns a shallow copy of r carrying s
+func withSettings(r *http.Request, s *settings) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), settingsKey{}, s))
+}
+
+// settingsFrom returns the settings bound to r, or zero settings
+func settingsFrom(r *http.Request) *settings {
+	if s, ok := r.Context().Value(settingsKey{}).(*settings); ok {
+		return s
+	}
+	return &settings{}
+}
+
+// details holds the optional response content enabled by Features
+type details struct {
+	Timestamp  string
+	Method     string
+	Path       string
+	RequestID  string
+	RetryAfter int64
+	ErrorID    string
+	DocURL     string
+	Causes     []Cause
+	Category   Classification
+	Stack      string
+	Panic      PanicKind
+}
+
+// collectDetails gathers the optional content for an error according to f
+func collectDetails(r *http.Request, err HTTPError, f Features) details {
+	var d details
+	if f.Timestamps {
+		now := time.Now
+		if f.Now != nil {
+			now = f.Now
+		}
+		d.Timestamp = f.timestamp(now())
+	}
+	if f.RequestInfo {
+		d.Method = r.Method
+		if r.URL != nil {
+			d.Path = r.URL.Path
+		}
+	}
+	if id, ok := requestIDFrom(r); ok {
+		d.RequestID = id.id
+	}
+	if info := rateLimitOf(err); f.RetryAfter || info != nil {
+		d.RetryAfter = retryAfterSeconds(err)
+		if d.RetryAfter == 0 && info != nil {
+			d.RetryAfter = info.ResetSeconds
+		}
+	}
+	if f.ErrorIDs {
+		d.ErrorID = settingsFrom(r).errorIDFor(err, f.IDs)
+	}
+	if f.DocURLs {
+		d.DocURL = f.docURL(err)
+	}
+	if f.Causes {
+		d.Causes = causesOf(err)
+	}
+	if f.Categories {
+		d.Category = ClassificationOf(err)
+	}
+	if f.Stacks {
+		d.Stack = stackOf(err)
+		d.Panic = panicKindOf(err)
+	}
+	return d
+}
+
+// timestamp formats t with the configured layout and zone
+func (f Features) timestamp(t time.Time) string {
+	zone, layout := f.TimeZone, f.TimeFormat
+	if zone == nil {
+		zone = time.UTC
+	}
+	if layout == "" {
+		layout = time.RFC3339
+	}
+	return t.In(zone).Format(layout)
+}
+
+// errorID returns the id of an error, generating one with ids when it has none
+func errorID(err HTTPError, ids IDGenerator) string {
+	if id, ok := ownErrorID(err); ok {
+		return id
+	}
+	if ids == nil {
+		ids = UUIDv7
+	}
+	return ids.NewID()
+}
+
+// errorIDFor returns the id of err, or else the one generated for the
+// request, generating one only outside negotiators
+func (s *settings) errorIDFor(err HTTPError, ids IDGenerator) string {
+	if _, ok := ownErrorID(err); !ok && s.errorID != "" {
+		return s.errorID
+	}
+	return errorID(err, ids)
+}
+
+// identifiedError carries the id generated for an error, so hooks, reports
+// and stores see the id sent to the client
+type identifiedError struct {
+	HTTPError
+	id string
+}
+
+// ErrorID returns the generated id
+func (e *identifiedError) ErrorID() string { return e.id }
+
+// Unwrap returns the original error
+func (e *identifiedError) Unwrap() error { return e.HTTPError }
+
+// identify gives err an id when it has none
+func identify(err HTTPError, ids IDGenerator) (HTTPError, string) {
+	if id, ok := ownErrorID(err); ok {
+		return err, id
+	}
+	id := errorID(err, ids)
+	return &identifiedError{HTTPError: err, id: id}, id
+}
+
+// ownErrorID returns the id of an error implementing ErrorID() string,
+// without generating one
+func ownErrorID(err HTTPError) (string, bool) {
+	var i interface{ ErrorID() string }
+	if errors.As(err, &i) && i.ErrorID() != "" {
+		return i.ErrorID(), true
+	}
+	return "", false
+}
+
+// docURL returns the documentation URL of an error, asking Registry and then
+// Docs when the error has none
+func (f Features) docURL(err HTTPError) string {
+	if u := docURL(err); u != "" {
+		return u
+	}
+	if u := f.Registry.docURL(err); u != "" || f.Docs == nil {
+		return u
+	}
+	return f.Docs.DocURL(err)
+}
+
+// docURL returns the documentation URL of an error, if any
+func docURL(err HTTPError) string {
+	var d interface{ DocURL() string }
+	if errors.As(err, &d) {
+		return d.DocURL()
+	}
+	return ""
+}
@@ -0,0 +1,80 @@
+// Package fibererr renders the errors of fiber applications through
+// httperrorfmt. Formatters write to a net/http ResponseWriter, so responses
+// are rendered into memory and copied to the fasthttp response.
+package fibererr
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/gofiber/fiber/v3/middleware/adaptor"
+	"github.com/perbu/httperrorfmt"
+)
+
+// Errors renders the errors of a fiber application through one formatter,
+// usually a shared ContentNegotiator
+type Errors struct {
+	// Formatter renders errors. Nil means httperrorfmt.Default.
+	Formatter httperrorfmt.Formatter
+	// Mapper converts errors that are neither HTTPErrors nor fiber.Errors.
+	// Nil turns them into 500s.
+	Mapper *httperrorfmt.ErrorMapper
+}
+
+// New creates an Errors rendering through formatter
+func New(formatter httperrorfmt.Formatter, mapper *httperrorfmt.ErrorMapper) *Errors {
+	return &Errors{Formatter: formatter, Mapper: mapper}
+}
+
+// Handler is a fiber.ErrorHandler:
+//
+//	app := fiber.New(fiber.Config{ErrorHandler: fibererr.New(negotiator, mapper).Handler})
+func (e *Errors) Handler(c fiber.Ctx, err error) error {
+	r, convErr := adaptor.ConvertRequest(c, false)
+	if convErr != nil {
+		return convErr
+	}
+	resp := httperrorfmt.Render(e.formatter(), r, e.Translate(err, c))
+
+	header := &c.Response().Header
+	for name, values := range resp.Header {
+		header.Del(name)
+		for _, value := range values {
+			header.Add(name, value)
+		}
+	}
+	return c.Status(resp.Status).Send(resp.Body)
+}
+
+// Translate converts err to an HTTPError. HTTPErrors are kept, fiber.Errors
+// keep their status and message, and other errors go through Mapper. The 405s
+// of fiber's router list the methods of the Allow header it set.
+func (e *Errors) Translate(err error, c fiber.Ctx) httperrorfmt.HTTPError {
+	var httpErr httperrorfmt.HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr
+	}
+	var fe *fiber.Error
+	if !errors.As(err, &fe) {
+		return e.Mapper.Map(err)
+	}
+	if fe.Code == http.StatusMethodNotAllowed {
+		var methods []string
+		for _, value := range c.GetRespHeaders()[fiber.HeaderAllow] {
+			methods = append(methods, strings.Split(value, ",")...)
+		}
+		return httperrorfmt.MethodNotAllowed(methods...)
+	}
+	message := fe.Message
+	if message == "" {
+		message = http.StatusText(fe.Code)
+	}
+	return httperrorfmt.Wrap(err, fe.Code, message)
+}
+
+// formatter returns Formatter or the package default
+func (e *Errors) formatter() httperrorfmt.Formatter {
+	return httperrorfmt.OrDefault(e.Formatter)
+}
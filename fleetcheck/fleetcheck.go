@@ -0,0 +1,216 @@
+// Package fleetcheck probes services with failing requests and reports where
+// their error responses disagree, to verify a consistent error experience
+// across a fleet
+package fleetcheck
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Probe is a request crafted to fail
+type Probe struct {
+	Name   string
+	Method string
+	Path   string
+	Accept string
+}
+
+// DefaultProbes request a path that doesn't exist with the common Accept headers
+var DefaultProbes = []Probe{
+	{Name: "not-found/json", Method: http.MethodGet, Path: "/__fleetcheck/not-found", Accept: "application/json"},
+	{Name: "not-found/problem", Method: http.MethodGet, Path: "/__fleetcheck/not-found", Accept: "application/problem+json"},
+	{Name: "not-found/html", Method: http.MethodGet, Path: "/__fleetcheck/not-found", Accept: "text/html"},
+	{Name: "not-found/text", Method: http.MethodGet, Path: "/__fleetcheck/not-found", Accept: "text/plain"},
+	{Name: "not-found/any", Method: http.MethodGet, Path: "/__fleetcheck/not-found", Accept: "*/*"},
+	{Name: "not-found/none", Method: http.MethodGet, Path: "/__fleetcheck/not-found"},
+}
+
+// Result is the response of one service to one probe
+type Result struct {
+	Service     string
+	Probe       Probe
+	Status      int
+	ContentType string
+	// Fields are the sorted top-level members of a JSON body
+	Fields []string
+	Err    error
+}
+
+// Inconsistency is an aspect of a probe's responses on which services disagree
+type Inconsistency struct {
+	Probe  string
+	Aspect string
+	// Services groups the services by the value they returned
+	Services map[string][]string
+}
+
+// Report holds the results of a check and the inconsistencies found
+type Report struct {
+	Results         []Result
+	Inconsistencies []Inconsistency
+}
+
+// Checker probes services
+type Checker struct {
+	// Client sends the probes. Nil means a client with a 10 second timeout.
+	Client *http.Client
+	// Probes are sent to every service. Nil means DefaultProbes.
+	Probes []Probe
+	// Concurrency caps the probes in flight. Zero means 8.
+	Concurrency int
+}
+
+// Check sends every probe to every service, given as base URLs, and compares
+// the responses
+func (c *Checker) Check(ctx context.Context, services []string) *Report {
+	client := c.Client
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	probes := c.Probes
+	if probes == nil {
+		probes = DefaultProbes
+	}
+
+	concurrency := c.Concurrency
+	if concurrency <= 0 {
+		concurrency = 8
+	}
+
+	results := make([]Result, len(services)*len(probes))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, service := range services {
+		for j, probe := range probes {
+			sem <- struct{}{}
+			wg.Go(func() {
+				defer func() { <-sem }()
+				results[i*len(probes)+j] = send(ctx, client, service, probe)
+			})
+		}
+	}
+	wg.Wait()
+
+	report := &Report{Results: results}
+	for _, probe := range probes {
+		report.Inconsistencies = append(report.Inconsistencies, compare(probe, results)...)
+	}
+	return report
+}
+
+// Failed returns the results of probes that got no response
+func (r *Report) Failed() []Result {
+	var failed []Result
+	for _, result := range r.Results {
+		if result.Err != nil {
+			failed = append(failed, result)
+		}
+	}
+	return failed
+}
+
+// OK reports whether every probe got a response and the services agree
+func (r *Report) OK() bool {
+	return len(r.Inconsistencies) == 0 && len(r.Failed()) == 0
+}
+
+// send runs one probe against one service
+func send(ctx context.Context, client *http.Client, service string, probe Probe) Result {
+	result := Result{Service: service, Probe: probe}
+	method := probe.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimSuffix(service, "/")+probe.Path, nil)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	if probe.Accept != "" {
+		req.Header.Set("Accept", probe.Accept)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	defer resp.Body.Close()
+
+	result.Status = resp.StatusCode
+	result.ContentType, _, _ = mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	var members map[string]json.RawMessage
+	if json.Unmarshal(body, &members) == nil {
+		for key := range members {
+			result.Fields = append(result.Fields, key)
+		}
+		slices.Sort(result.Fields)
+	}
+	return result
+}
+
+// compare groups the results of a probe by status, content type and fields
+// and reports each aspect on which services differ
+func compare(probe Probe, results []Result) []Inconsistency {
+	aspects := map[string]func(Result) string{
+		"status":       func(r Result) string { return fmt.Sprint(r.Status) },
+		"content-type": func(r Result) string { return r.ContentType },
+		"fields":       func(r Result) string { return strings.Join(r.Fields, ",") },
+	}
+
+	var inconsistencies []Inconsistency
+	for _, aspect := range []string{"status", "content-type", "fields"} {
+		groups := make(map[string][]string)
+		for _, result := range results {
+			if result.Probe.Name != probe.Name || result.Err != nil {
+				continue
+			}
+			value := aspects[aspect](result)
+			groups[value] = append(groups[value], result.Service)
+		}
+		if len(groups) > 1 {
+			inconsistencies = append(inconsistencies, Inconsistency{Probe: probe.Name, Aspect: aspect, Services: groups})
+		}
+	}
+	return inconsistencies
+}
+
+// WriteText writes a human readable report
+func (r *Report) WriteText(w io.Writer) {
+	failed := r.Failed()
+	for _, result := range failed {
+		fmt.Fprintf(w, "error   %-20s %s: %v\n", result.Probe.Name, result.Service, result.Err)
+	}
+	if r.OK() {
+		fmt.Fprintln(w, "all services agree")
+		return
+	}
+	if len(failed) > 0 {
+		fmt.Fprintf(w, "%d of %d probes failed\n", len(failed), len(r.Results))
+	}
+	for _, inconsistency := range r.Inconsistencies {
+		fmt.Fprintf(w, "differs %-20s %s\n", inconsistency.Probe, inconsistency.Aspect)
+		values := make([]string, 0, len(inconsistency.Services))
+		for value := range inconsistency.Services {
+			values = append(values, value)
+		}
+		slices.Sort(values)
+		for _, value := range values {
+			shown := value
+			if shown == "" {
+				shown = "(none)"
+			}
+			fmt.Fprintf(w, "        %-20s %s\n", shown, strings.Join(inconsistency.Services[value], ", "))
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package httperrorfmt
+
+import (
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// BodyLimit is the extension member with the byte limit a request body
+// exceeded, set by FormError for http.MaxBytesError
+var BodyLimit = NewExtensionKey[int64]("max_bytes")
+
+// FormError converts the errors of reading and parsing request bodies and
+// queries to 400s and 413s. Bodies over the limit of http.MaxBytesReader and
+// multipart forms over the memory limit give a 413 stating the limit;
+// malformed multipart forms, missing form files and invalid query escapes
+// give a 400 with a cause describing what failed. Other errors give nil, so
+// it can be added to an ErrorMapper:
+//
+//	mapper.Add(httperrorfmt.FormError)
+func FormError(err error) HTTPError {
+	if err == nil {
+		return nil
+	}
+	var maxBytesErr *http.MaxBytesError
+	var escapeErr url.EscapeError
+	switch {
+	case errors.As(err, &maxBytesErr):
+		return Wrap(err, http.StatusRequestEntityTooLarge, "Request body is too large").
+			WithCauses(Cause{Reason: "too_large", Message: fmt.Sprintf("The limit is %d bytes", maxBytesErr.Limit)}).
+			WithExtensions(BodyLimit.Value(maxBytesErr.Limit))
+	case errors.Is(err, multipart.ErrMessageTooLarge):
+		return Wrap(err, http.StatusRequestEntityTooLarge, "Multipart form is too large").
+			WithCauses(Cause{Reason: "too_large", Message: "The form fields exceed the size the server accepts"})
+	case errors.Is(err, http.ErrNotMultipart):
+		return Wrap(err, http.StatusBadRequest, "Request body is not a multipart form").
+			WithCauses(Cause{Reason: "content_type", Message: "The Content-Type must be multipart/form-data"})
+	case errors.Is(err, http.ErrMissingBoundary):
+		return Wrap(err, http.StatusBadRequest, "Malformed multipart form").
+			WithCauses(Cause{Reason: "boundary", Message: "The Content-Type has no boundary parameter"})
+	case errors.Is(err, http.ErrMissingFile):
+		return Wrap(err, http.StatusBadRequest, "Request is missing a file").
+			WithCauses(Cause{Reason: "missing_file", Message: "The form has no file with the expected name"})
+	case errors.As(err, &escapeErr):
+		return Wrap(err, http.StatusBadRequest, "Malformed query or form data").
+			WithCauses(Cause{Reason: "invalid_escape", Message: fmt.Sprintf("Invalid escape sequence %q", string(escapeErr))})
+	}
+
+	// The remaining errors have no type of their own
+	message := err.Error()
+	switch {
+	case message == "http: POST too large":
+		return Wrap(err, http.StatusRequestEntityTooLarge, "Request body is too large").
+			WithCauses(Cause{Reason: "too_large", Message: "The form exceeds the size the server accepts"})
+	case strings.Contains(message, "invalid semicolon separator in query"):
+		return Wrap(err, http.StatusBadRequest, "Malformed query or form data").
+			WithCauses(Cause{Reason: "invalid_separator", Message: "Use & rather than ; to separate parameters"})
+	case strings.Contains(message, "number of URL query parameters exceeded limit"):
+		return Wrap(err, http.StatusBadRequest, "Too many query or form parameters").
+			WithCauses(Cause{Reason: "too_many_parameters", Message: "The number of parameters exceeds the limit"})
+	case strings.HasPrefix(message, "multipart: "):
+		return Wrap(err, http.StatusBadRequest, "Malformed multipart form").
+			WithCauses(Cause{Reason: "multipart", Message: strings.TrimPrefix(message, "multipart: ")})
+	}
+	return nil
+}
@@ -0,0 +1,27 @@
+package httperrorfmt
+
+import (
+	"net/http"
+	"net/netip"
+)
+
+// FormatHintHeader is set by an edge proxy that already negotiated the
+// representation. Its value has the syntax of an Accept header.
+const FormatHintHeader = "X-Negotiate-Format"
+
+// TrustFormatHint makes the negotiator honour FormatHintHeader in place of
+// Accept when the request comes directly from one of the given networks. The
+// header is ignored for everyone else, so clients can't spoof it.
+func (cn *ContentNegotiator) TrustFormatHint(proxies ...netip.Prefix) *ContentNegotiator {
+	cn.trustedProxies = append(cn.trustedProxies, proxies...)
+	return cn
+}
+
+// accept returns the Accept value to negotiate with, preferring a trusted
+// format hint
+func (cn *ContentNegotiator) accept(r *http.Request) string {
+	if hint := r.Header.Get(FormatHintHeader); hint != "" && fromNetworks(r, cn.trustedProxies) {
+		return hint
+	}
+	return r.Header.Get("Accept")
+}
@@ -5,7 +5,14 @@ import (
 	"encoding/xml"
 	"fmt"
 	"html/template"
+	"maps"
+	"mime"
 	"net/http"
+	"net/netip"
+	"path"
+	"regexp"
+	"runtime/trace"
+	"slices"
 	"strings"
 )
 
@@ -26,40 +33,147 @@ type Formatter interface {
 type JSONFormatter struct {
 	PrettyPrint  bool
 	IncludeStack bool
+	// PlainLanguage puts the plain-language message in "error" and the
+	// original message in "technical_detail"
+	PlainLanguage bool
+	// JSONPCallback names the query parameter carrying a JSONP callback, e.g.
+	// "callback". JSONP is disabled when empty; invalid callback names are ignored.
+	// JSONP responses are sent with status 200, since browsers don't run scripts
+	// of error responses; the real status is the status member of the payload.
+	JSONPCallback string
+	// Shape renames, drops and nests members of the body. Nil writes ErrorResponse as is.
+	Shape *JSONShape
 }
 
 // ErrorResponse represents a JSON error response
 type ErrorResponse struct {
-	Error  string `json:"error"`
-	Status int    `json:"status"`
-	Code   string `json:"code,omitempty"`
+	Error           string         `json:"error"`
+	Status          int            `json:"status"`
+	Code            string         `json:"code,omitempty"`
+	ErrorCode       string         `json:"error_code,omitempty"`
+	Category        Classification `json:"category,omitempty"`
+	TechnicalDetail string         `json:"technical_detail,omitempty"`
+	ErrorID         string         `json:"error_id,omitempty"`
+	Timestamp       string         `json:"timestamp,omitempty"`
+	Method          string         `json:"method,omitempty"`
+	Path            string         `json:"path,omitempty"`
+	RequestID       string         `json:"request_id,omitempty"`
+	RetryAfter      int64          `json:"retry_after,omitempty"`
+	Retryable       *bool          `json:"retryable,omitempty"`
+	IdempotencySafe *bool          `json:"idempotency_safe,omitempty"`
+	RateLimit       *RateLimitInfo `json:"rate_limit,omitempty"`
+	AllowedMethods  []string       `json:"allowed_methods,omitempty"`
+	BlockedBy       string         `json:"blocked_by,omitempty"`
+	HelpURL         string         `json:"help_url,omitempty"`
+	Remediation     *Remediation   `json:"remediation,omitempty"`
+	Causes          []Cause        `json:"causes,omitempty"`
+	Items           []BatchItem    `json:"items,omitempty"`
+	Panic           string         `json:"panic,omitempty"`
+	Stack           string         `json:"stack,omitempty"`
 }
 
 // Format implements Formatter interface for JSON responses
 func (f *JSONFormatter) Format(w http.ResponseWriter, r *http.Request, err HTTPError) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(err.StatusCode())
+	r, err = normalize(r, err)
+	callback := f.jsonpCallback(r)
+	if callback != "" {
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		writeHeader(okWriter{w}, r, err, "application/javascript; charset=utf-8")
+	} else {
+		writeHeader(w, r, err, "application/json; charset=utf-8")
+	}
 
 	response := ErrorResponse{
-		Error:  err.Message(),
-		Status: err.StatusCode(),
-		Code:   http.StatusText(err.StatusCode()),
+		Error:     publicMessage(err),
+		Status:    err.StatusCode(),
+		Code:      statusText(err.StatusCode()),
+		ErrorCode: errorCode(err),
+	}
+	if f.PlainLanguage {
+		if plain, ok := plainMessage(err); ok {
+			response.Error = plain
+			response.TechnicalDetail = publicMessage(err)
+		}
+	}
+
+	s := settingsFrom(r)
+	features := s.features
+	features.Stacks = features.Stacks || f.IncludeStack && !s.sanitized
+	d := collectDetails(r, err, features)
+	response.Category = d.Category
+	response.ErrorID = d.ErrorID
+	response.Timestamp = d.Timestamp
+	response.Method = d.Method
+	response.Path = d.Path
+	response.RequestID = d.RequestID
+	response.RetryAfter = d.RetryAfter
+	hints := retryHintsOf(err)
+	response.Retryable = hints.Retryable
+	response.IdempotencySafe = hints.IdempotencySafe
+	response.RateLimit = rateLimitOf(err)
+	response.AllowedMethods = allowedMethodsOf(err)
+	response.BlockedBy = blockedByOf(err)
+	response.HelpURL = d.DocURL
+	response.Remediation = remediationOf(err)
+	response.Causes = d.Causes
+	response.Panic = string(d.Panic)
+	response.Stack = d.Stack
+	response.Items, _ = batchItemsOf(err)
+
+	var body any = response
+	if f.Shape != nil {
+		body = f.Shape.build(response, err)
 	}
 
 	var data []byte
 	if f.PrettyPrint {
-		data, _ = json.MarshalIndent(response, "", "  ")
+		data, _ = json.MarshalIndent(body, "", "  ")
 	} else {
-		data, _ = json.Marshal(response)
+		data, _ = json.Marshal(body)
 	}
 
+	if callback != "" {
+		// The leading comment defuses content sniffing attacks on the callback
+		data = append(append([]byte("/**/"+callback+"("), data...), ");"...)
+	}
 	w.Write(data)
 }
 
+// okWriter sends every status as 200, for JSONP responses
+type okWriter struct {
+	http.ResponseWriter
+}
+
+// WriteHeader sends 200 whatever the status
+func (w okWriter) WriteHeader(int) { w.ResponseWriter.WriteHeader(http.StatusOK) }
+
+// jsonpCallbackPattern matches safe JSONP callbacks: dotted JavaScript identifiers
+var jsonpCallbackPattern = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*(\.[A-Za-z_$][A-Za-z0-9_$]*)*$`)
+
+// jsonpCallback returns the validated JSONP callback of a request, if any
+func (f *JSONFormatter) jsonpCallback(r *http.Request) string {
+	if f.JSONPCallback == "" || r.URL == nil {
+		return ""
+	}
+	callback := r.URL.Query().Get(f.JSONPCallback)
+	if len(callback) > 128 || !jsonpCallbackPattern.MatchString(callback) {
+		return ""
+	}
+	return callback
+}
+
 // HTMLFormatter formats errors as HTML
 type HTMLFormatter struct {
 	Template     *template.Template
 	TemplateName string
+	// PlainLanguage shows the plain-language message when the error has one
+	PlainLanguage bool
+	// EmbedJSON adds a <script type="application/json" id="error-data"> block with
+	// the structured error for single page apps
+	EmbedJSON bool
+	// Limits bounds template execution. A template that exceeds them is replaced
+	// by the built-in fallback page.
+	Limits TemplateLimits
 }
 
 // DefaultHTMLTemplate is a basic error template
@@ -80,10 +194,86 @@ const DefaultHTMLTemplate = `<!DOCTYPE html>
         <div class="error-code">{{.Status}}</div>
         <div class="error-message">{{.Error}}</div>
         <div class="error-details">{{.Code}}</div>
+        {{- if .Causes}}
+        <ul class="error-details">
+            {{- range .Causes}}
+            <li>{{if .Field}}{{.Field}}: {{end}}{{.Message}}</li>
+            {{- end}}
+        </ul>
+        {{- end}}
+        {{- if .HelpURL}}
+        <div class="error-details"><a href="{{.HelpURL}}">More information</a></div>
+        {{- end}}
+        {{- if .ErrorID}}
+        <div class="error-details">Error ID: {{.ErrorID}}</div>
+        {{- end}}
+        {{- if .AllowedMethods}}
+        <div class="error-details">Allowed methods: {{range $i, $m := .AllowedMethods}}{{if $i}}, {{end}}{{$m}}{{end}}</div>
+        {{- end}}
+        {{- if .BlockedBy}}
+        <div class="error-details">Blocked by: <a href="{{.BlockedBy}}">{{.BlockedBy}}</a></div>
+        {{- end}}
+        {{- if .RetryAfter}}
+        <div class="error-details">Please try again in {{.RetryIn}}.</div>
+        {{- end}}
+        {{- if .RequestID}}
+        <div class="error-details">Request ID: {{.RequestID}}</div>
+        {{- end}}
+        {{- if .Timestamp}}
+        <div class="error-details">{{.Timestamp}}</div>
+        {{- end}}
+        {{- if .Path}}
+        <div class="error-details">{{.Method}} {{.Path}}</div>
+        {{- end}}
+        {{- if .Stack}}
+        <pre class="error-details">{{.Stack}}</pre>
+        {{- end}}
     </div>
+    {{- if .Embedded}}
+    <script type="application/json" id="error-data">{{.Embedded}}</script>
+    {{- end}}
 </body>
 </html>`
 
+// TemplateData is the data HTML templates are executed with
+type TemplateData struct {
+	Error      string
+	Status     int
+	Code       string
+	Category   Classification
+	ErrorID    string
+	Timestamp  string
+	Method     string
+	Path       string
+	RequestID  string
+	RetryAfter int64
+	// AllowedMethods lists the methods of 405 errors
+	AllowedMethods []string
+	// BlockedBy is the entity implementing a 451 block
+	BlockedBy string
+	HelpURL   string
+	Causes    []Cause
+	Stack     string
+	// Embedded is set when EmbedJSON is enabled
+	Embedded *EmbeddedError
+	// Language is the language of the response: that of the translated
+	// message, or else the client's preferred one. The Number, Date, Duration
+	// and RetryIn methods format values for it.
+	Language string
+
+	localizer Localizer
+}
+
+// EmbeddedError is the machine-readable error embedded in HTML pages
+type EmbeddedError struct {
+	Status    int    `json:"status"`
+	Code      string `json:"code"`
+	Message   string `json:"message"`
+	ErrorCode string `json:"error_code,omitempty"`
+	ErrorID   string `json:"error_id,omitempty"`
+	RequestID string `json:"request_id,omitempty"`
+}
+
 // NewHTMLFormatter creates a new HTML formatter with default template
 func NewHTMLFormatter() *HTMLFormatter {
 	tmpl, _ := template.New("error").Parse(DefaultHTMLTemplate)
@@ -95,86 +285,519 @@ func NewHTMLFormatter() *HTMLFormatter {
 
 // Format implements Formatter interface for HTML responses
 func (f *HTMLFormatter) Format(w http.ResponseWriter, r *http.Request, err HTTPError) {
-	w.Header().Set("Content-Type", "text/html; charset=utf-8")
-	w.WriteHeader(err.StatusCode())
+	r, err = normalize(r, err)
+	writeHeader(w, r, err, "text/html; charset=utf-8")
+
+	message := publicMessage(err)
+	if f.PlainLanguage {
+		if plain, ok := plainMessage(err); ok {
+			message = plain
+		}
+	}
 
-	data := struct {
-		Error  string
-		Status int
-		Code   string
-	}{
-		Error:  err.Message(),
-		Status: err.StatusCode(),
-		Code:   http.StatusText(err.StatusCode()),
+	features := settingsFrom(r).features
+	d := collectDetails(r, err, features)
+	data := TemplateData{
+		Error:          message,
+		Status:         err.StatusCode(),
+		Code:           statusText(err.StatusCode()),
+		Category:       d.Category,
+		ErrorID:        d.ErrorID,
+		Timestamp:      d.Timestamp,
+		Method:         d.Method,
+		Path:           d.Path,
+		RequestID:      d.RequestID,
+		RetryAfter:     d.RetryAfter,
+		AllowedMethods: allowedMethodsOf(err),
+		BlockedBy:      blockedByOf(err),
+		HelpURL:        d.DocURL,
+		Causes:         d.Causes,
+		Stack:          d.Stack,
+		Language:       responseLanguage(r, err),
+		localizer:      features.Localizer,
+	}
+	if f.EmbedJSON {
+		data.Embedded = &EmbeddedError{
+			Status:    err.StatusCode(),
+			Code:      statusText(err.StatusCode()),
+			Message:   message,
+			ErrorCode: errorCode(err),
+			ErrorID:   d.ErrorID,
+			RequestID: d.RequestID,
+		}
 	}
 
-	if f.Template != nil {
+	if f.Template != nil && f.Limits.enabled() {
+		if f.Limits.execute(w, f.Template, f.TemplateName, data) == nil {
+			return
+		}
+	} else if f.Template != nil && f.TemplateName != "" {
 		f.Template.ExecuteTemplate(w, f.TemplateName, data)
-	} else {
-		// Fallback to simple HTML
-		fmt.Fprintf(w, "<h1>%d %s</h1><p>%s</p>",
-			err.StatusCode(), http.StatusText(err.StatusCode()), err.Message())
+		return
+	} else if f.Template != nil {
+		f.Template.Execute(w, data)
+		return
 	}
+
+	// Fallback to simple HTML
+	fmt.Fprintf(w, "<h1>%d %s</h1><p>%s</p>",
+		err.StatusCode(), statusText(err.StatusCode()), template.HTMLEscapeString(message))
 }
 
 // TextFormatter formats errors as plain text
-type TextFormatter struct{}
+type TextFormatter struct {
+	// PlainLanguage writes the plain-language message when the error has one
+	PlainLanguage bool
+	// Latin1 sends ISO-8859-1 to clients whose Accept-Charset prefers it over
+	// UTF-8. Characters outside Latin-1 are replaced with "?".
+	Latin1 bool
+}
 
 // Format implements Formatter interface for plain text responses
 func (f *TextFormatter) Format(w http.ResponseWriter, r *http.Request, err HTTPError) {
-	w.Header().Set("Content-Type", "text/plain")
-	w.WriteHeader(err.StatusCode())
-	w.Write([]byte(err.Message()))
+	r, err = normalize(r, err)
+	if f.Latin1 && prefersLatin1(r) {
+		writeHeader(w, r, err, "text/plain; charset=iso-8859-1")
+		w = &latin1Writer{ResponseWriter: w}
+	} else {
+		writeHeader(w, r, err, "text/plain; charset=utf-8")
+	}
+
+	message := publicMessage(err)
+	if f.PlainLanguage {
+		if plain, ok := plainMessage(err); ok {
+			message = plain
+		}
+	}
+	w.Write([]byte(message))
+
+	features := settingsFrom(r).features
+	d := collectDetails(r, err, features)
+	for _, cause := range d.Causes {
+		if cause.Field != "" {
+			fmt.Fprintf(w, "\n- %s: %s", cause.Field, cause.Message)
+		} else {
+			fmt.Fprintf(w, "\n- %s", cause.Message)
+		}
+	}
+	if d.DocURL != "" {
+		fmt.Fprintf(w, "\n\nMore information: %s", d.DocURL)
+	}
+	if d.ErrorID != "" {
+		fmt.Fprintf(w, "\nError ID: %s", d.ErrorID)
+	}
+	if d.RequestID != "" {
+		fmt.Fprintf(w, "\nRequest ID: %s", d.RequestID)
+	}
+	if d.RetryAfter > 0 {
+		data := TemplateData{RetryAfter: d.RetryAfter, Language: responseLanguage(r, err), localizer: features.Localizer}
+		fmt.Fprintf(w, "\nRetry after: %s", data.RetryIn())
+	}
+	if blockedBy := blockedByOf(err); blockedBy != "" {
+		fmt.Fprintf(w, "\nBlocked by: %s", blockedBy)
+	}
+	if d.Timestamp != "" {
+		fmt.Fprintf(w, "\nTime: %s", d.Timestamp)
+	}
+	if d.Path != "" {
+		fmt.Fprintf(w, "\nRequest: %s %s", d.Method, d.Path)
+	}
+	if d.Stack != "" {
+		fmt.Fprintf(w, "\n\n%s", d.Stack)
+	}
 }
 
 // ContentNegotiator allows registration of formatters for different content types
 type ContentNegotiator struct {
 	formatters map[string]Formatter
+	aliases    map[string]string
 	defaults   Formatter
+	features   Features
+	extensions map[string]string
+	vary       []string
+	strict     bool
+	tracing    bool
+	grpc       bool
+
+	detectBrowsers bool
+
+	prefixes        map[string]Formatter
+	trustedProxies  []netip.Prefix
+	statuses        map[int]Formatter
+	versionSelector VersionSelector
+	versions        map[string]*ContentNegotiator
+	store           ErrorStore
+	redactor        Redactor
+	disclosure      DisclosurePolicy
+
+	postProcessors []PostProcessor
+	hooks          []Hook
 }
 
 // NewContentNegotiator creates a new content negotiator
 func NewContentNegotiator() *ContentNegotiator {
 	return &ContentNegotiator{
 		formatters: make(map[string]Formatter),
+		aliases:    make(map[string]string),
 		defaults:   &TextFormatter{},
 	}
 }
 
-// Register adds a formatter for a specific content type
+// Register adds a formatter for a specific content type. Nil formatters,
+// including typed nil pointers, are ignored.
 func (cn *ContentNegotiator) Register(contentType string, formatter Formatter) *ContentNegotiator {
-	cn.formatters[contentType] = formatter
+	if formatter == nil || isNilPointer(formatter) {
+		return cn
+	}
+	if cn.formatters == nil {
+		cn.formatters = make(map[string]Formatter)
+	}
+	cn.formatters[normalizeMediaType(contentType)] = formatter
 	return cn
 }
 
-// SetDefault sets the default formatter when no content type matches
+// Alias maps a near-standard media type sent by clients onto a registered content type
+func (cn *ContentNegotiator) Alias(alias, contentType string) *ContentNegotiator {
+	if cn.aliases == nil {
+		cn.aliases = make(map[string]string)
+	}
+	cn.aliases[strings.ToLower(alias)] = normalizeMediaType(contentType)
+	return cn
+}
+
+// SetDefault sets the default formatter, used when no content type matches
+// and for clients that send no Accept header or only */*
 func (cn *ContentNegotiator) SetDefault(formatter Formatter) *ContentNegotiator {
 	cn.defaults = formatter
 	return cn
 }
 
+// SetFeatures sets the optional content every registered formatter renders
+func (cn *ContentNegotiator) SetFeatures(features Features) *ContentNegotiator {
+	cn.features = features
+	return cn
+}
+
+// UseExtensions makes the path extension (/users/42.json) take precedence over the
+// Accept header. The table maps extensions such as ".json" to media types; pass
+// DefaultExtensions for the common ones, or nil to turn the lookup off again.
+func (cn *ContentNegotiator) UseExtensions(table map[string]string) *ContentNegotiator {
+	cn.extensions = table
+	return cn
+}
+
+// SetVary sets the request headers listed in the Vary header of every negotiated
+// response, so shared caches keep representations apart. The default is Accept;
+// call SetVary() with no headers to leave Vary alone.
+func (cn *ContentNegotiator) SetVary(headers ...string) *ContentNegotiator {
+	cn.vary = append([]string{}, headers...)
+	return cn
+}
+
+// SetStrict makes the negotiator answer 406 Not Acceptable when no registered
+// formatter matches the Accept header, instead of falling back to the default.
+// The 406 lists the supported media types and is rendered the way a lenient
+// negotiator would pick. Requests without an Accept header still get the default.
+func (cn *ContentNegotiator) SetStrict(strict bool) *ContentNegotiator {
+	cn.strict = strict
+	return cn
+}
+
+// ServeGRPC makes the negotiator answer requests with an application/grpc
+// content type through GRPCFormatter, so gRPC clients that hit an HTTP error
+// path get a gRPC status instead of an unreadable body
+func (cn *ContentNegotiator) ServeGRPC(enabled bool) *ContentNegotiator {
+	cn.grpc = enabled
+	return cn
+}
+
+// Prefix makes every request whose path starts with prefix use formatter,
+// regardless of its Accept header. The longest matching prefix wins. Use it for
+// sections with a fixed error format, such as OAuth endpoints under /oauth/.
+func (cn *ContentNegotiator) Prefix(prefix string, formatter Formatter) *ContentNegotiator {
+	if cn.prefixes == nil {
+		cn.prefixes = make(map[string]Formatter)
+	}
+	cn.prefixes[prefix] = formatter
+	return cn
+}
+
+// OverrideStatus makes errors with the given status use formatter regardless
+// of the Accept header, e.g. a maintenance page for 503. Pass a status class
+// such as 5 to cover 500-599; exact statuses take precedence over classes, and
+// both over path prefixes.
+func (cn *ContentNegotiator) OverrideStatus(status int, formatter Formatter) *ContentNegotiator {
+	if cn.statuses == nil {
+		cn.statuses = make(map[int]Formatter)
+	}
+	cn.statuses[status] = formatter
+	return cn
+}
+
 // Format implements Formatter interface with pluggable content negotiation
 func (cn *ContentNegotiator) Format(w http.ResponseWriter, r *http.Request, err HTTPError) {
-	accept := r.Header.Get("Accept")
+	cn.format(w, r, err)
+}
 
-	// Parse Accept header and find best match
-	contentType := cn.parseAcceptHeader(accept)
+// format formats err and returns the error that was sent, which differs from
+// err when strict negotiation replaced it with a 406. Hooks see that error.
+func (cn *ContentNegotiator) format(w http.ResponseWriter, r *http.Request, err HTTPError) (sent HTTPError) {
+	if versioned := cn.versioned(w, r); versioned != nil && versioned != cn {
+		sent = versioned.format(w, r, err)
+		if len(cn.hooks) > 0 {
+			r, _ = normalize(r, err)
+			runHooks(r, sent, cn.hooks)
+		}
+		return sent
+	}
+	if r != nil {
+		if state := debugFrom(r); state != nil {
+			state.begin(err)
+			defer func() { state.end(err) }()
+		}
+	}
+	r, err = normalize(r, err)
+	if cn == nil {
+		cn = &ContentNegotiator{}
+	}
+	disclosure := DiscloseConfigured
+	if cn.disclosure != nil {
+		disclosure = cn.disclosure.Disclosure(r, err)
+	}
+	if cn.redactor != nil {
+		err = Redact(err, cn.redactor)
+	} else if disclosure == DiscloseSanitized {
+		err = Redact(err, DefaultRedactors)
+	}
+	s := &settings{features: cn.features, tracing: cn.tracing}
+	s.disclose(disclosure)
+	if cn.store != nil && err.StatusCode() >= 500 {
+		err = storeError(r, err, cn.store, cn.features.IDs)
+		s.features.ErrorIDs = true
+	}
+	if s.features.ErrorIDs {
+		err, s.errorID = identify(err, s.features.IDs)
+	}
+	r = withSettings(r, s)
+	if cn.features.RequestID != nil {
+		r = withRequestID(r, cn.features.RequestID)
+	}
+	if cn.tracing && trace.IsEnabled() {
+		var task *trace.Task
+		r, task = startTrace(r, err)
+		defer task.End()
+	}
+	if cn.vary == nil {
+		addVary(w.Header(), "Accept")
+	} else {
+		addVary(w.Header(), cn.vary...)
+	}
+	if len(cn.trustedProxies) > 0 {
+		addVary(w.Header(), FormatHintHeader)
+	}
+	if cn.detectBrowsers {
+		addVary(w.Header(), "Sec-Fetch-Mode", "Sec-Fetch-Dest", "User-Agent", "X-Requested-With")
+	}
+
+	sent = err
+	if len(cn.hooks) > 0 {
+		defer func() { runHooks(r, sent, cn.hooks) }()
+	}
+
+	// Nobody reads the response of a request the client abandoned. Hooks still
+	// see the error.
+	if clientGone(r) {
+		return sent
+	}
+
+	if len(cn.postProcessors) == 0 {
+		sent = cn.dispatch(w, r, err)
+		return sent
+	}
+
+	// Render into a buffer so post-processors see the complete response
+	buffer := newResponseBuffer(w.Header())
+	sent = cn.dispatch(buffer, r, err)
+	resp := buffer.response()
+	endRegion := traceRegion(r, "httperrorfmt.postprocess")
+	for _, processor := range cn.postProcessors {
+		processor.PostProcess(r, sent, resp)
+	}
+	endRegion()
+	writeResponse(w, resp)
+	return sent
+}
+
+// dispatch hands the error to the formatter matching the request and returns
+// the error it formatted
+func (cn *ContentNegotiator) dispatch(w http.ResponseWriter, r *http.Request, err HTTPError) HTTPError {
+	endRegion := traceRegion(r, "httperrorfmt.select")
+	formatter, err := cn.selectFormatter(r, err)
+	endRegion()
+
+	defer traceRegion(r, "httperrorfmt.render")()
+	formatter.Format(w, r, err)
+	return err
+}
+
+// selectFormatter picks the formatter for a request. In strict mode the error
+// is replaced by a 406 when nothing registered is acceptable.
+func (cn *ContentNegotiator) selectFormatter(r *http.Request, err HTTPError) (Formatter, HTTPError) {
+	if cn.grpc && isGRPC(r) {
+		return &GRPCFormatter{}, err
+	}
+	if formatter, ok := cn.statuses[err.StatusCode()]; ok {
+		return formatter, err
+	}
+	if formatter, ok := cn.statuses[err.StatusCode()/100]; ok {
+		return formatter, err
+	}
+	if formatter, ok := cn.matchPrefix(r); ok {
+		return formatter, err
+	}
+
+	contentType, acceptable := cn.selectContentType(r)
+	if !acceptable {
+		err = cn.notAcceptable(err)
+		contentType = cn.lenientContentType(cn.accept(r))
+	}
 
 	// Look up formatter for content type
 	if formatter, exists := cn.formatters[contentType]; exists {
-		formatter.Format(w, r, err)
-		return
+		return formatter, err
 	}
 
 	// Fall back to default formatter
-	cn.defaults.Format(w, r, err)
+	if cn.defaults == nil || isNilPointer(cn.defaults) {
+		return &TextFormatter{}, err
+	}
+	return cn.defaults, err
+}
+
+// matchPrefix returns the formatter bound to the longest prefix of the request path
+func (cn *ContentNegotiator) matchPrefix(r *http.Request) (Formatter, bool) {
+	if len(cn.prefixes) == 0 || r.URL == nil {
+		return nil, false
+	}
+	best := ""
+	var formatter Formatter
+	for prefix, f := range cn.prefixes {
+		if strings.HasPrefix(r.URL.Path, prefix) && len(prefix) >= len(best) {
+			best, formatter = prefix, f
+		}
+	}
+	return formatter, formatter != nil
+}
+
+// selectContentType picks the content type for a request, trying the path
+// extension before the Accept header. It reports false when the negotiator is
+// strict and nothing registered is acceptable.
+func (cn *ContentNegotiator) selectContentType(r *http.Request) (string, bool) {
+	if contentType, ok := cn.matchExtension(r); ok {
+		return contentType, true
+	}
+	accept := cn.accept(r)
+	if contentType, ok := cn.detectClient(r, accept); ok {
+		return contentType, true
+	}
+	if cn.strict && accept != "" {
+		return cn.matchAccept(accept)
+	}
+	return cn.parseAcceptHeader(accept), true
+}
+
+// matchAccept finds the registered content type the client prefers most,
+// honouring q-values and wildcards. An empty content type selects the default.
+func (cn *ContentNegotiator) matchAccept(accept string) (string, bool) {
+	for _, entry := range parseWeighted(accept) {
+		if entry.Q == 0 {
+			continue
+		}
+		mediaType := entry.Value
+		if target, ok := cn.aliases[mediaType]; ok {
+			mediaType = target
+		}
+		switch {
+		case mediaType == "*/*":
+			return "", true
+		case strings.HasSuffix(mediaType, "/*"):
+			prefix := strings.TrimSuffix(mediaType, "*")
+			for _, contentType := range cn.supported() {
+				if strings.HasPrefix(contentType, prefix) {
+					return contentType, true
+				}
+			}
+		default:
+			if contentType, ok := cn.resolve(entry); ok {
+				return contentType, true
+			}
+		}
+	}
+	return "", false
+}
+
+// lenientContentType picks a representation for the 406 itself, avoiding
+// media types the client refused with q=0
+func (cn *ContentNegotiator) lenientContentType(accept string) string {
+	contentType := cn.parseAcceptHeader(accept)
+	for _, entry := range parseWeighted(accept) {
+		if entry.Q == 0 && entry.Value == contentType {
+			return ""
+		}
+	}
+	return contentType
+}
+
+// supported returns the registered content types in sorted order
+func (cn *ContentNegotiator) supported() []string {
+	return slices.Sorted(maps.Keys(cn.formatters))
+}
+
+// notAcceptable builds the 406 sent in place of err in strict mode. It only
+// lists the supported types; err survives in the internal message for logs
+// and hooks, but its causes and metadata never reach the body.
+func (cn *ContentNegotiator) notAcceptable(err HTTPError) HTTPError {
+	message := "None of the requested media types are available. Supported: " +
+		strings.Join(cn.supported(), ", ")
+	return New(http.StatusNotAcceptable, message).
+		WithInternal(fmt.Sprintf("httperrorfmt: not acceptable, in place of %d: %s", err.StatusCode(), err.Error()))
+}
+
+// matchExtension resolves the request path extension to a registered content type
+func (cn *ContentNegotiator) matchExtension(r *http.Request) (string, bool) {
+	if len(cn.extensions) == 0 || r.URL == nil {
+		return "", false
+	}
+	ext := strings.ToLower(path.Ext(r.URL.Path))
+	if ext == "" {
+		return "", false
+	}
+	mediaType, ok := cn.extensions[ext]
+	if !ok {
+		return "", false
+	}
+	if target, ok := cn.aliases[mediaType]; ok {
+		mediaType = target
+	}
+	_, ok = cn.formatters[mediaType]
+	return mediaType, ok
 }
 
 // parseAcceptHeader performs simple Accept header parsing
 func (cn *ContentNegotiator) parseAcceptHeader(accept string) string {
-	// Handle empty Accept header
-	if accept == "" {
-		return "text/plain"
+	// Clients without a preference, sending no Accept header or only */*, get
+	// the default formatter
+	if acceptsAnything(accept) {
+		return ""
+	}
+
+	// Media types with parameters select variants registered for them
+	for _, entry := range parseWeighted(accept) {
+		if entry.Q > 0 && len(entry.Params) > 0 {
+			if contentType, ok := cn.resolve(entry); ok && strings.Contains(contentType, ";") {
+				return contentType
+			}
+		}
 	}
 
 	// Simple implementation - just look for known types
@@ -192,10 +815,65 @@ func (cn *ContentNegotiator) parseAcceptHeader(accept string) string {
 		return "text/plain"
 	}
 
+	// Look for other registered types and aliases in the order the client listed them
+	if contentType, ok := cn.matchRegistered(accept); ok {
+		return contentType
+	}
+
 	// Default to text/plain for unknown types
 	return "text/plain"
 }
 
+// matchRegistered finds the first Accept entry that resolves to a registered formatter
+func (cn *ContentNegotiator) matchRegistered(accept string) (string, bool) {
+	for _, part := range strings.Split(accept, ",") {
+		entry, ok := parseWeightedValue(part)
+		if !ok {
+			continue
+		}
+		if contentType, ok := cn.resolve(entry); ok {
+			return contentType, true
+		}
+	}
+	return "", false
+}
+
+// resolve finds the registered content type for an Accept entry. Aliases are
+// applied first. Among registrations of the media type, the one whose
+// parameters all appear in the entry and that has the most of them wins, so
+// application/json;version=2 picks a formatter registered for version=2 and
+// falls back to plain application/json.
+func (cn *ContentNegotiator) resolve(entry weightedValue) (string, bool) {
+	mediaType := entry.Value
+	if target, ok := cn.aliases[mediaType]; ok {
+		mediaType = target
+	}
+	if _, ok := cn.formatters[mediaType]; ok && len(entry.Params) == 0 {
+		return mediaType, true
+	}
+
+	best, bestParams := "", -1
+	for contentType := range cn.formatters {
+		base, params, err := mime.ParseMediaType(contentType)
+		if err != nil || base != mediaType || !paramsMatch(params, entry.Params) {
+			continue
+		}
+		if len(params) > bestParams || (len(params) == bestParams && contentType < best) {
+			best, bestParams = contentType, len(params)
+		}
+	}
+	return best, bestParams >= 0
+}
+
+// DefaultExtensions maps common path extensions to media types for UseExtensions
+var DefaultExtensions = map[string]string{
+	".json": "application/json",
+	".xml":  "application/xml",
+	".html": "text/html",
+	".htm":  "text/html",
+	".txt":  "text/plain",
+}
+
 // ContentNegotiatingFormatter provides backward compatibility
 type ContentNegotiatingFormatter struct {
 	*ContentNegotiator
@@ -207,6 +885,13 @@ func NewContentNegotiatingFormatter() *ContentNegotiatingFormatter {
 		Register("application/json", &JSONFormatter{PrettyPrint: true}).
 		Register("text/html", NewHTMLFormatter()).
 		Register("text/plain", &TextFormatter{}).
+		Register("application/vnd.error+json", &VndErrorFormatter{PrettyPrint: true}).
+		Register("application/problem+json", &ProblemFormatter{PrettyPrint: true}).
+		Alias("application/json5", "application/json").
+		Alias("application/x-json", "application/json").
+		Alias("text/json", "application/json").
+		Alias("text/x-json", "application/json").
+		Alias("application/vnd.error", "application/vnd.error+json").
 		SetDefault(&TextFormatter{})
 
 	return &ContentNegotiatingFormatter{
@@ -215,27 +900,76 @@ func NewContentNegotiatingFormatter() *ContentNegotiatingFormatter {
 }
 
 // XMLFormatter formats errors as XML
-type XMLFormatter struct{}
+type XMLFormatter struct {
+	// PlainLanguage puts the plain-language message in <message> and the
+	// original message in <technical_detail>
+	PlainLanguage bool
+}
 
 // XMLErrorResponse represents the XML structure for error responses
 type XMLErrorResponse struct {
-	XMLName xml.Name `xml:"error"`
-	Message string   `xml:"message"`
-	Status  int      `xml:"status"`
-	Code    string   `xml:"code"`
+	XMLName         xml.Name   `xml:"error"`
+	Message         string     `xml:"message"`
+	Status          int        `xml:"status"`
+	Code            string     `xml:"code"`
+	ErrorCode       string     `xml:"error_code,omitempty"`
+	Category        string     `xml:"category,omitempty"`
+	TechnicalDetail string     `xml:"technical_detail,omitempty"`
+	ErrorID         string     `xml:"error_id,omitempty"`
+	Timestamp       string     `xml:"timestamp,omitempty"`
+	Method          string     `xml:"method,omitempty"`
+	Path            string     `xml:"path,omitempty"`
+	RequestID       string     `xml:"request_id,omitempty"`
+	RetryAfter      int64      `xml:"retry_after,omitempty"`
+	Retryable       *bool      `xml:"retryable,omitempty"`
+	IdempotencySafe *bool      `xml:"idempotency_safe,omitempty"`
+	BlockedBy       string     `xml:"blocked_by,omitempty"`
+	HelpURL         string     `xml:"help_url,omitempty"`
+	Causes          *XMLCauses `xml:"causes,omitempty"`
+	Stack           string     `xml:"stack,omitempty"`
+}
+
+// XMLCauses wraps the causes of an XML error response
+type XMLCauses struct {
+	Causes []Cause `xml:"cause"`
 }
 
 // Format implements Formatter interface for XML responses
 func (f *XMLFormatter) Format(w http.ResponseWriter, r *http.Request, err HTTPError) {
-	w.Header().Set("Content-Type", "application/xml")
-	w.WriteHeader(err.StatusCode())
+	r, err = normalize(r, err)
+	writeHeader(w, r, err, "application/xml; charset=utf-8")
 
 	response := XMLErrorResponse{
-		Message: err.Message(),
-		Status:  err.StatusCode(),
-		Code:    http.StatusText(err.StatusCode()),
+		Message:   publicMessage(err),
+		Status:    err.StatusCode(),
+		Code:      statusText(err.StatusCode()),
+		ErrorCode: errorCode(err),
+	}
+	if f.PlainLanguage {
+		if plain, ok := plainMessage(err); ok {
+			response.Message = plain
+			response.TechnicalDetail = publicMessage(err)
+		}
 	}
 
+	d := collectDetails(r, err, settingsFrom(r).features)
+	response.Category = string(d.Category)
+	response.ErrorID = d.ErrorID
+	response.Timestamp = d.Timestamp
+	response.Method = d.Method
+	response.Path = d.Path
+	response.RequestID = d.RequestID
+	response.RetryAfter = d.RetryAfter
+	hints := retryHintsOf(err)
+	response.Retryable = hints.Retryable
+	response.IdempotencySafe = hints.IdempotencySafe
+	response.BlockedBy = blockedByOf(err)
+	response.HelpURL = d.DocURL
+	if len(d.Causes) > 0 {
+		response.Causes = &XMLCauses{Causes: d.Causes}
+	}
+	response.Stack = d.Stack
+
 	// Write XML declaration manually since encoding/xml doesn't include it
 	w.Write([]byte(xml.Header))
 
@@ -249,21 +983,21 @@ type DefaultFormatter struct{}
 
 // Format implements Formatter interface with simple content negotiation
 func (f *DefaultFormatter) Format(w http.ResponseWriter, r *http.Request, err HTTPError) {
+	r, err = normalize(r, err)
 	accept := r.Header.Get("Accept")
 
-	w.WriteHeader(err.StatusCode())
-
 	if strings.Contains(accept, "application/json") {
-		w.Header().Set("Content-Type", "application/json")
+		writeHeader(w, r, err, "application/json; charset=utf-8")
 		response := ErrorResponse{
-			Error:  err.Message(),
-			Status: err.StatusCode(),
-			Code:   http.StatusText(err.StatusCode()),
+			Error:     publicMessage(err),
+			Status:    err.StatusCode(),
+			Code:      statusText(err.StatusCode()),
+			ErrorCode: errorCode(err),
 		}
 		data, _ := json.Marshal(response)
 		w.Write(data)
 	} else {
-		w.Header().Set("Content-Type", "text/plain")
-		w.Write([]byte(err.Message()))
+		writeHeader(w, r, err, "text/plain; charset=utf-8")
+		w.Write([]byte(publicMessage(err)))
 	}
 }
@@ -0,0 +1,120 @@
+// Package gatewayerr renders the errors of grpc-gateway through httperrorfmt,
+// so REST endpoints transcoded from gRPC share the error surface of the rest
+// of the application
+package gatewayerr
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/perbu/httperrorfmt"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Errors renders gateway errors through one formatter, usually a shared
+// ContentNegotiator
+type Errors struct {
+	// Formatter renders errors. Nil means httperrorfmt.Default.
+	Formatter httperrorfmt.Formatter
+}
+
+// New creates an Errors rendering through formatter
+func New(formatter httperrorfmt.Formatter) *Errors {
+	return &Errors{Formatter: formatter}
+}
+
+// ServeMuxOptions returns the options that install Handler and
+// RoutingHandler:
+//
+//	mux := runtime.NewServeMux(gatewayerr.New(negotiator).ServeMuxOptions()...)
+func (e *Errors) ServeMuxOptions() []runtime.ServeMuxOption {
+	return []runtime.ServeMuxOption{
+		runtime.WithErrorHandler(e.Handler),
+		runtime.WithRoutingErrorHandler(e.RoutingHandler),
+	}
+}
+
+// Handler is a runtime.ErrorHandlerFunc rendering err with FromError
+func (e *Errors) Handler(_ context.Context, _ *runtime.ServeMux, _ runtime.Marshaler, w http.ResponseWriter, r *http.Request, err error) {
+	e.formatter().Format(w, r, FromError(err))
+}
+
+// RoutingHandler is a runtime.RoutingErrorHandlerFunc rendering the 404, 405
+// and 400 responses of the gateway's router
+func (e *Errors) RoutingHandler(_ context.Context, _ *runtime.ServeMux, _ runtime.Marshaler, w http.ResponseWriter, r *http.Request, httpStatus int) {
+	var err httperrorfmt.HTTPError
+	switch httpStatus {
+	case http.StatusNotFound:
+		err = httperrorfmt.New(http.StatusNotFound, "Not found")
+	case http.StatusMethodNotAllowed:
+		err = httperrorfmt.MethodNotAllowed()
+	case http.StatusBadRequest:
+		err = httperrorfmt.New(http.StatusBadRequest, "Bad request")
+	default:
+		err = httperrorfmt.New(http.StatusInternalServerError, "Internal server error")
+	}
+	e.formatter().Format(w, r, err)
+}
+
+// formatter returns Formatter or the package default
+func (e *Errors) formatter() httperrorfmt.Formatter {
+	return httperrorfmt.OrDefault(e.Formatter)
+}
+
+// FromError converts a gateway error to an HTTPError. HTTPErrors are kept.
+// The gRPC code of a status becomes the HTTP status the gateway would use, an
+// ErrorInfo detail the error code, BadRequest field violations the causes and
+// RetryInfo the Retry-After header. Messages of internal, unknown and data
+// loss errors aren't meant for clients and are replaced with the status text.
+// A runtime.HTTPStatusError overrides the status.
+func FromError(err error) httperrorfmt.HTTPError {
+	var httpErr httperrorfmt.HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr
+	}
+	s := status.Convert(err)
+	httpStatus := runtime.HTTPStatusFromCode(s.Code())
+	var statusErr *runtime.HTTPStatusError
+	if errors.As(err, &statusErr) {
+		httpStatus = statusErr.HTTPStatus
+	}
+
+	message := s.Message()
+	switch s.Code() {
+	case codes.Internal, codes.Unknown, codes.DataLoss:
+		message = ""
+	}
+	if message == "" {
+		message = http.StatusText(httpStatus)
+	}
+
+	e := httperrorfmt.Wrap(err, httpStatus, message)
+	for _, detail := range s.Details() {
+		switch d := detail.(type) {
+		case *errdetails.ErrorInfo:
+			e.WithCode(d.GetReason())
+		case *errdetails.BadRequest:
+			for _, v := range d.GetFieldViolations() {
+				e.WithCauses(httperrorfmt.Cause{Reason: v.GetReason(), Message: v.GetDescription(), Field: v.GetField()})
+			}
+		case *errdetails.RetryInfo:
+			e.WithRetryAfter(d.GetRetryDelay().AsDuration())
+		}
+	}
+	return e
+}
+
+// Mapping is an httperrorfmt.Mapping for gRPC status errors, for handlers
+// that call gRPC clients:
+//
+//	mapper.Add(gatewayerr.Mapping)
+func Mapping(err error) httperrorfmt.HTTPError {
+	if _, ok := status.FromError(err); !ok {
+		return nil
+	}
+	return FromError(err)
+}
@@ -0,0 +1,58 @@
+// Package ginerr renders the errors of gin handlers through httperrorfmt
+// instead of gin's default JSON
+package ginerr
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/perbu/httperrorfmt"
+)
+
+// Errors renders the errors of a gin application through one formatter,
+// usually a shared ContentNegotiator
+type Errors struct {
+	// Formatter renders errors. Nil means httperrorfmt.Default.
+	Formatter httperrorfmt.Formatter
+	// Mapper converts errors that aren't HTTPErrors. Nil turns them into 500s.
+	Mapper *httperrorfmt.ErrorMapper
+}
+
+// New creates an Errors rendering through formatter
+func New(formatter httperrorfmt.Formatter, mapper *httperrorfmt.ErrorMapper) *Errors {
+	return &Errors{Formatter: formatter, Mapper: mapper}
+}
+
+// Middleware returns gin middleware that renders the last error handlers
+// added to c.Errors once they are done, unless a response was written. An
+// error status set with c.Status is kept for errors that aren't HTTPErrors;
+// other errors go through Mapper. c.AbortWithError and c.Bind write the
+// status right away and leave nothing to render, so use c.Error with c.Abort
+// and c.ShouldBind instead.
+func (e *Errors) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+		e.Format(c, c.Errors.Last().Err)
+	}
+}
+
+// Format renders err as the response of c and aborts the chain
+func (e *Errors) Format(c *gin.Context, err error) {
+	var httpErr httperrorfmt.HTTPError
+	if status := c.Writer.Status(); !errors.As(err, &httpErr) && status >= 400 {
+		httpErr = httperrorfmt.Wrap(err, status, http.StatusText(status))
+	} else {
+		httpErr = e.Mapper.Map(err)
+	}
+	c.Abort()
+	e.formatter().Format(c.Writer, c.Request, httpErr)
+}
+
+// formatter returns Formatter or the package default
+func (e *Errors) formatter() httperrorfmt.Formatter {
+	return httperrorfmt.OrDefault(e.Formatter)
+}
@@ -0,0 +1,20 @@
+package httperrorfmt
+
+import "net/http"
+
+// OutputVersion is the version of the rendered error format. Within a version
+// the built-in formatters render byte-identical status lines, headers and
+// bodies for the same error, request and configuration in every release, so
+// teams that hash, sign or golden-test error bodies can upgrade safely. A
+// release that changes existing output bumps the version. New optional content
+// that is off by default doesn't count as a change.
+const OutputVersion = 1
+
+// Render formats err with f into memory and returns the response, for golden
+// tests of error bodies. Set Features.Now and Features.IDs to fixed values so
+// timestamps and generated ids are reproducible.
+func Render(f Formatter, r *http.Request, err HTTPError) *Response {
+	buf := newResponseBuffer(http.Header{})
+	OrDefault(f).Format(buf, r, err)
+	return buf.response()
+}
@@ -0,0 +1,114 @@
+package httperrorfmt
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// update rewrites the golden files: go test -run TestGolden -update
+var update = flag.Bool("update", false, "update golden files")
+
+// goldenFeatures enables the optional content with a fixed clock and ids, so
+// renders are reproducible
+var goldenFeatures = Features{
+	Timestamps:  true,
+	Now:         func() time.Time { return time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC) },
+	RequestInfo: true,
+	ErrorIDs:    true,
+	IDs:         IDGeneratorFunc(func() string { return "01HNBXK5Z8Q4W1S2T3V4X5Y6Z7" }),
+	RetryAfter:  true,
+	Causes:      true,
+}
+
+// goldenErrors are the errors every formatter renders
+var goldenErrors = []struct {
+	name string
+	err  func() HTTPError
+}{
+	{"not_found", func() HTTPError {
+		return New(http.StatusNotFound, "User not found").WithCode("USER_NOT_FOUND")
+	}},
+	{"validation", func() HTTPError {
+		return New(http.StatusUnprocessableEntity, "The request contains invalid values").
+			WithCauses(Cause{Field: "email", Reason: "required", Message: "Email is required"})
+	}},
+	{"unavailable", func() HTTPError {
+		return ServiceUnavailable(FixedDelay(30*time.Second), 1)
+	}},
+}
+
+func TestGolden(t *testing.T) {
+	formatters := []struct {
+		name      string
+		formatter Formatter
+	}{
+		{"json", &JSONFormatter{}},
+		{"json_pretty", &JSONFormatter{PrettyPrint: true}},
+		{"html", NewHTMLFormatter()},
+		{"text", &TextFormatter{}},
+		{"xml", &XMLFormatter{}},
+		{"problem", &ProblemFormatter{}},
+		{"vnderror", &VndErrorFormatter{}},
+		{"hal", &HALFormatter{}},
+		{"google", &GoogleErrorFormatter{}},
+		{"kubernetes", &KubernetesStatusFormatter{}},
+		{"scim", &SCIMFormatter{}},
+		{"oauth", &OAuthFormatter{}},
+		{"odata", &ODataFormatter{}},
+		{"twirp", &TwirpFormatter{}},
+		{"aws", &AWSErrorFormatter{}},
+		{"soap", &SOAPFormatter{}},
+		{"ndjson", &NDJSONFormatter{}},
+		{"soft", &SoftErrorFormatter{}},
+		{"terminal", &TerminalFormatter{}},
+		{"grpc", &GRPCFormatter{}},
+	}
+	for _, tt := range formatters {
+		for _, e := range goldenErrors {
+			name := tt.name + "_" + e.name
+			t.Run(name, func(t *testing.T) {
+				negotiator := NewContentNegotiator().SetDefault(tt.formatter).SetFeatures(goldenFeatures)
+				r := httptest.NewRequest(http.MethodGet, "/users/42?expand=roles", nil)
+				r.Header.Set("Accept", "*/*")
+				got := dumpResponse(Render(negotiator, r, e.err()))
+
+				path := filepath.Join("testdata", name+".golden")
+				if *update {
+					if err := os.WriteFile(path, got, 0o644); err != nil {
+						t.Fatal(err)
+					}
+					return
+				}
+				want, err := os.ReadFile(path)
+				if err != nil {
+					t.Fatalf("missing golden file, run with -update: %v", err)
+				}
+				if !bytes.Equal(got, want) {
+					t.Errorf("output of %s changed; bump OutputVersion if intended\ngot:\n%s\nwant:\n%s", name, got, want)
+				}
+			})
+		}
+	}
+}
+
+// dumpResponse writes the status, headers in key order and body of resp
+func dumpResponse(resp *Response) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "%d\n", resp.Status)
+	for _, key := range slices.Sorted(maps.Keys(resp.Header)) {
+		fmt.Fprintf(&b, "%s: %s\n", key, strings.Join(resp.Header[key], ", "))
+	}
+	b.WriteString("\n")
+	b.Write(resp.Body)
+	return b.Bytes()
+}
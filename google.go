@@ -0,0 +1,125 @@
+package httperrorfmt
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+// GoogleErrorFormatter formats errors using the Google API error model (AIP-193)
+type GoogleErrorFormatter struct {
+	PrettyPrint bool
+	// Domain is reported in ErrorInfo details, e.g. "library.example.com"
+	Domain string
+}
+
+// GoogleErrorResponse is the envelope of a Google API error
+type GoogleErrorResponse struct {
+	Error GoogleError `json:"error"`
+}
+
+// GoogleError represents the google.rpc.Status carried in the envelope
+type GoogleError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Status  string `json:"status"`
+	Details []any  `json:"details,omitempty"`
+}
+
+// GoogleErrorInfo represents a google.rpc.ErrorInfo detail
+type GoogleErrorInfo struct {
+	Type     string            `json:"@type"`
+	Reason   string            `json:"reason"`
+	Domain   string            `json:"domain,omitempty"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// GoogleBadRequest represents a google.rpc.BadRequest detail
+type GoogleBadRequest struct {
+	Type            string                 `json:"@type"`
+	FieldViolations []GoogleFieldViolation `json:"fieldViolations"`
+}
+
+// GoogleFieldViolation represents a single BadRequest field violation
+type GoogleFieldViolation struct {
+	Field       string `json:"field"`
+	Description string `json:"description"`
+	Reason      string `json:"reason,omitempty"`
+}
+
+// Format implements Formatter interface for Google API error responses.
+// An ErrorInfo detail is added for errors implementing ErrorCode() string, with
+// metadata from Metadata() map[string]string. Causes with a field become
+// BadRequest field violations.
+func (f *GoogleErrorFormatter) Format(w http.ResponseWriter, r *http.Request, err HTTPError) {
+	r, err = normalize(r, err)
+	writeHeader(w, r, err, "application/json")
+
+	response := GoogleErrorResponse{
+		Error: GoogleError{
+			Code:    err.StatusCode(),
+			Message: publicMessage(err),
+			Status:  canonicalCode(err.StatusCode()),
+		},
+	}
+
+	if reason := errorCode(err); reason != "" {
+		response.Error.Details = append(response.Error.Details, GoogleErrorInfo{
+			Type:     "type.googleapis.com/google.rpc.ErrorInfo",
+			Reason:   reason,
+			Domain:   f.Domain,
+			Metadata: errorMetadata(err),
+		})
+	}
+
+	var violations []GoogleFieldViolation
+	for _, cause := range causesOf(err) {
+		if cause.Field == "" {
+			continue
+		}
+		violations = append(violations, GoogleFieldViolation{
+			Field:       cause.Field,
+			Description: cause.Message,
+			Reason:      cause.Reason,
+		})
+	}
+	if len(violations) > 0 {
+		response.Error.Details = append(response.Error.Details, GoogleBadRequest{
+			Type:            "type.googleapis.com/google.rpc.BadRequest",
+			FieldViolations: violations,
+		})
+	}
+
+	var data []byte
+	if f.PrettyPrint {
+		data, _ = json.MarshalIndent(response, "", "  ")
+	} else {
+		data, _ = json.Marshal(response)
+	}
+
+	w.Write(data)
+}
+
+// errorCode returns the application specific error code, if any
+func errorCode(err HTTPError) string {
+	var c interface{ ErrorCode() string }
+	if errors.As(err, &c) {
+		return c.ErrorCode()
+	}
+	return ""
+}
+
+// errorMetadata returns the metadata attached to an error, if any
+func errorMetadata(err HTTPError) map[string]string {
+	var m interface{ Metadata() map[string]string }
+	if errors.As(err, &m) {
+		return m.Metadata()
+	}
+	return nil
+}
+
+// canonicalCode maps a status code onto the google.rpc.Code name, as
+// ToGRPCCode maps it
+func canonicalCode(status int) string {
+	return grpcCodeNames[ToGRPCCode(status)]
+}
@@ -0,0 +1,143 @@
+package httperrorfmt
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// gRPC status codes, as defined by google.golang.org/grpc/codes
+const (
+	grpcCanceled           = 1
+	grpcUnknown            = 2
+	grpcInvalidArgument    = 3
+	grpcDeadlineExceeded   = 4
+	grpcNotFound           = 5
+	grpcAlreadyExists      = 6
+	grpcPermissionDenied   = 7
+	grpcResourceExhausted  = 8
+	grpcFailedPrecondition = 9
+	grpcAborted            = 10
+	grpcOutOfRange         = 11
+	grpcUnimplemented      = 12
+	grpcInternal           = 13
+	grpcUnavailable        = 14
+	grpcDataLoss           = 15
+	grpcUnauthenticated    = 16
+)
+
+// grpcCodeNames are the names of the gRPC status codes, indexed by code
+var grpcCodeNames = [...]string{
+	"OK", "CANCELLED", "UNKNOWN", "INVALID_ARGUMENT", "DEADLINE_EXCEEDED", "NOT_FOUND",
+	"ALREADY_EXISTS", "PERMISSION_DENIED", "RESOURCE_EXHAUSTED", "FAILED_PRECONDITION",
+	"ABORTED", "OUT_OF_RANGE", "UNIMPLEMENTED", "INTERNAL", "UNAVAILABLE", "DATA_LOSS",
+	"UNAUTHENTICATED",
+}
+
+// FromGRPCCode returns the HTTP status for a gRPC status code, as gRPC-Gateway
+// and the Connect protocol map them. OK gives 200 and unknown codes give 500.
+func FromGRPCCode(code uint32) int {
+	switch code {
+	case 0:
+		return http.StatusOK
+	case grpcCanceled:
+		return StatusClientClosedRequest
+	case grpcInvalidArgument, grpcFailedPrecondition, grpcOutOfRange:
+		return http.StatusBadRequest
+	case grpcDeadlineExceeded:
+		return http.StatusGatewayTimeout
+	case grpcNotFound:
+		return http.StatusNotFound
+	case grpcAlreadyExists, grpcAborted:
+		return http.StatusConflict
+	case grpcPermissionDenied:
+		return http.StatusForbidden
+	case grpcResourceExhausted:
+		return http.StatusTooManyRequests
+	case grpcUnimplemented:
+		return http.StatusNotImplemented
+	case grpcUnavailable:
+		return http.StatusServiceUnavailable
+	case grpcUnauthenticated:
+		return http.StatusUnauthorized
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// ToGRPCCode returns the closest gRPC status code for an HTTP status. Other
+// client errors give FAILED_PRECONDITION and other server errors INTERNAL.
+func ToGRPCCode(status int) uint32 {
+	switch status {
+	case http.StatusBadRequest, http.StatusUnprocessableEntity:
+		return grpcInvalidArgument
+	case http.StatusUnauthorized:
+		return grpcUnauthenticated
+	case http.StatusForbidden:
+		return grpcPermissionDenied
+	case http.StatusNotFound:
+		return grpcNotFound
+	case http.StatusConflict:
+		return grpcAlreadyExists
+	case http.StatusPreconditionFailed:
+		return grpcFailedPrecondition
+	case http.StatusRequestedRangeNotSatisfiable:
+		return grpcOutOfRange
+	case http.StatusTooManyRequests:
+		return grpcResourceExhausted
+	case StatusClientClosedRequest:
+		return grpcCanceled
+	case http.StatusNotImplemented, http.StatusMethodNotAllowed:
+		return grpcUnimplemented
+	case http.StatusServiceUnavailable, http.StatusBadGateway:
+		return grpcUnavailable
+	case http.StatusGatewayTimeout:
+		return grpcDeadlineExceeded
+	}
+	if status >= 200 && status < 300 {
+		return 0
+	}
+	if status >= 400 && status < 500 {
+		return grpcFailedPrecondition
+	}
+	return grpcInternal
+}
+
+// GRPCFormatter answers gRPC clients with a Trailers-Only response: status
+// 200, content type application/grpc and the error in the grpc-status and
+// grpc-message fields, which gRPC clients read as the status of the call
+type GRPCFormatter struct{}
+
+// Format implements Formatter interface for gRPC responses
+func (f *GRPCFormatter) Format(w http.ResponseWriter, r *http.Request, err HTTPError) {
+	r, err = normalize(r, err)
+	header := w.Header()
+	header.Del("Content-Length")
+	header.Set("Content-Type", "application/grpc")
+	header.Set("Grpc-Status", strconv.FormatUint(uint64(ToGRPCCode(err.StatusCode())), 10))
+	header.Set("Grpc-Message", grpcMessage(publicMessage(err)))
+	w.WriteHeader(http.StatusOK)
+}
+
+// isGRPC reports whether a request comes from a gRPC client
+func isGRPC(r *http.Request) bool {
+	contentType := r.Header.Get("Content-Type")
+	return contentType == "application/grpc" || strings.HasPrefix(contentType, "application/grpc+") ||
+		strings.HasPrefix(contentType, "application/grpc;")
+}
+
+// grpcMessage percent-encodes a message for grpc-message, as the gRPC HTTP/2
+// protocol requires for bytes outside printable ASCII and for '%'
+func grpcMessage(message string) string {
+	var b strings.Builder
+	for i := 0; i < len(message); i++ {
+		c := message[i]
+		if c < ' ' || c > '~' || c == '%' {
+			fmt.Fprintf(&b, "%%%02X", c)
+			continue
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
@@ -0,0 +1,64 @@
+package httperrorfmt
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// HALFormatter formats errors as HAL documents (application/hal+json)
+type HALFormatter struct {
+	PrettyPrint bool
+	// Links are added to every response, keyed by relation (e.g. "help", "about")
+	Links map[string]string
+	// Resolver adds per-error links, overriding static Links with the same relation
+	Resolver LinkResolver
+}
+
+// HALErrorResponse represents an error rendered as a HAL resource
+type HALErrorResponse struct {
+	Message string             `json:"message"`
+	Status  int                `json:"status"`
+	Code    string             `json:"code,omitempty"`
+	Links   map[string]HALLink `json:"_links"`
+}
+
+// HALLink represents a HAL link object
+type HALLink struct {
+	Href string `json:"href"`
+}
+
+// Format implements Formatter interface for HAL responses. The "self" link
+// always points at the requested resource.
+func (f *HALFormatter) Format(w http.ResponseWriter, r *http.Request, err HTTPError) {
+	r, err = normalize(r, err)
+	writeHeader(w, r, err, "application/hal+json")
+
+	links := make(map[string]HALLink, len(f.Links)+1)
+	for rel, href := range f.Links {
+		links[rel] = HALLink{Href: href}
+	}
+	if f.Resolver != nil {
+		for rel, href := range f.Resolver.ResolveLinks(r, err) {
+			links[rel] = HALLink{Href: href}
+		}
+	}
+	if r.URL != nil {
+		links["self"] = HALLink{Href: r.URL.RequestURI()}
+	}
+
+	response := HALErrorResponse{
+		Message: publicMessage(err),
+		Status:  err.StatusCode(),
+		Code:    statusText(err.StatusCode()),
+		Links:   links,
+	}
+
+	var data []byte
+	if f.PrettyPrint {
+		data, _ = json.MarshalIndent(response, "", "  ")
+	} else {
+		data, _ = json.Marshal(response)
+	}
+
+	w.Write(data)
+}
@@ -0,0 +1,32 @@
+package httperrorfmt
+
+import (
+	"net/http"
+	"strings"
+)
+
+// NotFoundHandler returns a handler that renders a 404 through formatter, for
+// the not found slot of routers such as chi's NotFound or gorilla/mux's
+// Router.NotFoundHandler. With http.ServeMux, register it for "/". A nil
+// formatter means Default.
+func NotFoundHandler(formatter Formatter) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		OrDefault(formatter).Format(w, r, New(http.StatusNotFound, "Not found"))
+	})
+}
+
+// MethodNotAllowedHandler returns a handler that renders a 405 through
+// formatter, for slots such as chi's MethodNotAllowed or gorilla/mux's
+// Router.MethodNotAllowedHandler. Without allowed methods, those of an Allow
+// header the router already set are used. A nil formatter means Default.
+func MethodNotAllowedHandler(formatter Formatter, allowed ...string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		methods := allowed
+		if len(methods) == 0 {
+			for _, value := range w.Header().Values("Allow") {
+				methods = append(methods, strings.Split(value, ",")...)
+			}
+		}
+		OrDefault(formatter).Format(w, r, MethodNotAllowed(methods...))
+	})
+}
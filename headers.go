@@ -0,0 +1,155 @@
+package httperrorfmt
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// writeHeader sends the status line and headers of an error response. The error's
+// own headers are applied, headers describing a previous representation that don't
+// hold for the error body are removed, request headers meant to be echoed are
+// copied, and the content type is set.
+func writeHeader(w http.ResponseWriter, r *http.Request, err HTTPError, contentType string) {
+	header := w.Header()
+	ownRange := false
+	for key, value := range err.Headers() {
+		if invalid := validateHeader(key, value); invalid != nil {
+			reportMisuse(r, invalid)
+			continue
+		}
+		header.Set(key, value)
+		ownRange = ownRange || http.CanonicalHeaderKey(key) == "Content-Range"
+	}
+
+	setChallengeHeaders(header, err)
+	if allowed := allowedMethodsOf(err); len(allowed) > 0 && err.StatusCode() == http.StatusMethodNotAllowed {
+		header.Set("Allow", strings.Join(allowed, ", "))
+	}
+	if blockedBy := blockedByOf(err); blockedBy != "" && err.StatusCode() == http.StatusUnavailableForLegalReasons {
+		header.Add("Link", "<"+blockedBy+`>; rel="blocked-by"`)
+	}
+	if f := settingsFrom(r).features; f.HelpLinks {
+		if help := f.docURL(err); help != "" && headerSafe(help) == help {
+			header.Add("Link", "<"+help+`>; rel="help"`)
+		}
+	}
+	if info := rateLimitOf(err); info != nil {
+		setRateLimitHeaders(header, info)
+	}
+	setRetryHintHeaders(header, retryHintsOf(err))
+
+	// Let clients match failed retries to their original attempt
+	if key := IdempotencyKey(r); key != "" {
+		header.Set("Idempotency-Key", key)
+	}
+	if id, ok := requestIDFrom(r); ok {
+		header.Set(id.header, id.id)
+	}
+
+	// Translated messages depend on the request's preferred languages
+	if translationsOf(err) != nil {
+		addVary(header, "Accept-Language")
+	}
+	if language := contentLanguage(err); language != "" {
+		header.Set("Content-Language", language)
+	}
+
+	// The error body is never a partial representation. Only 416 carries a
+	// Content-Range, reporting the current length of the resource, and only
+	// when the error supplies it; one left by the handler describes a range.
+	header.Del("Content-Length")
+	if err.StatusCode() == http.StatusRequestedRangeNotSatisfiable && !ownRange {
+		header.Del("Content-Range")
+	}
+	if err.StatusCode() != http.StatusRequestedRangeNotSatisfiable {
+		header.Del("Content-Range")
+		header.Del("Accept-Ranges")
+	}
+
+	header.Set("Content-Type", contentType)
+	w.WriteHeader(err.StatusCode())
+}
+
+// IdempotencyKey returns the Idempotency-Key of a request, if any, stripped of
+// characters that can't be echoed in a header
+func IdempotencyKey(r *http.Request) string {
+	return headerSafe(r.Header.Get("Idempotency-Key"))
+}
+
+// RangeNotSatisfiable creates a 416 error for a resource of the given size,
+// carrying the "Content-Range: bytes */size" header RFC 9110 requires
+func RangeNotSatisfiable(size int64) *Error {
+	return New(http.StatusRequestedRangeNotSatisfiable, "The requested range is not satisfiable").
+		WithHeader("Content-Range", fmt.Sprintf("bytes */%d", size)).
+		WithHeader("Accept-Ranges", "bytes")
+}
+
+// statusText is http.StatusText extended with widely used non-standard codes
+func statusText(status int) string {
+	if text := http.StatusText(status); text != "" {
+		return text
+	}
+	switch status {
+	case 460:
+		return "Checksum Mismatch"
+	case 499:
+		return "Client Closed Request"
+	default:
+		return ""
+	}
+}
+
+// addVary adds header names to Vary, skipping names already listed
+func addVary(h http.Header, names ...string) {
+	for _, name := range names {
+		name = http.CanonicalHeaderKey(name)
+		if !varies(h, name) {
+			h.Add("Vary", name)
+		}
+	}
+}
+
+// varies reports whether Vary already lists name or *
+func varies(h http.Header, name string) bool {
+	for _, value := range h.Values("Vary") {
+		for _, field := range strings.Split(value, ",") {
+			field = strings.TrimSpace(field)
+			if field == "*" || strings.EqualFold(field, name) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// maxHeaderValue caps the length of a header value taken from an error
+const maxHeaderValue = 8 << 10
+
+// validateHeader reports why a header attached to an error can't be sent.
+// Names must be tokens and values may not contain control characters other
+// than tab, so attacker influenced values can't split the response.
+func validateHeader(key, value string) error {
+	if key == "" || strings.IndexFunc(key, func(c rune) bool { return !isTokenChar(c) }) >= 0 {
+		return fmt.Errorf("httperrorfmt: invalid header name %q", key)
+	}
+	if len(value) > maxHeaderValue {
+		return fmt.Errorf("httperrorfmt: header %s value exceeds %d bytes", key, maxHeaderValue)
+	}
+	for _, c := range value {
+		if c < 0x20 && c != '\t' || c == 0x7f {
+			return fmt.Errorf("httperrorfmt: header %s value contains control character %q", key, c)
+		}
+	}
+	return nil
+}
+
+// isTokenChar reports whether c may appear in an RFC 9110 token
+func isTokenChar(c rune) bool {
+	switch {
+	case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		return true
+	default:
+		return strings.ContainsRune("!#$%&'*+-.^_`|~", c)
+	}
+}
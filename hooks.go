@@ -0,0 +1,37 @@
+package httperrorfmt
+
+import "net/http"
+
+// Hook observes formatted errors, e.g. for logging, metrics or alerting
+type Hook func(r *http.Request, err HTTPError)
+
+// OnError adds hooks called, in order, for every error the negotiator formats,
+// after the response is written. Hooks see the error that was sent, such as
+// the 406 of strict negotiation, including errors formatted by version
+// negotiators. Mounted negotiators don't copy hooks, since those of cn already
+// run for mounted requests.
+func (cn *ContentNegotiator) OnError(hooks ...Hook) *ContentNegotiator {
+	cn.hooks = append(cn.hooks, hooks...)
+	return cn
+}
+
+// HookedFormatter calls hooks for every error it formats, after delegating
+// to Formatter
+type HookedFormatter struct {
+	Formatter Formatter
+	Hooks     []Hook
+}
+
+// Format implements Formatter interface by delegating and then calling the hooks
+func (f *HookedFormatter) Format(w http.ResponseWriter, r *http.Request, err HTTPError) {
+	r, err = normalize(r, err)
+	OrDefault(f.Formatter).Format(w, r, err)
+	runHooks(r, err, f.Hooks)
+}
+
+// runHooks calls each hook with the formatted error
+func runHooks(r *http.Request, err HTTPError, hooks []Hook) {
+	for _, hook := range hooks {
+		hook(r, err)
+	}
+}
@@ -0,0 +1,15 @@
+package httperrorfmt
+
+import "net/http"
+
+// Default is the formatter used by ErrorString. Replace it at startup to change
+// how migrated http.Error calls render.
+var Default Formatter = NewContentNegotiatingFormatter()
+
+// ErrorString replies to the request with the given message and status through
+// Default. It mirrors http.Error so call sites can be migrated mechanically:
+//
+//	gofmt -r 'http.Error(w, m, s) -> httperrorfmt.ErrorString(w, r, m, s)'
+func ErrorString(w http.ResponseWriter, r *http.Request, msg string, status int) {
+	OrDefault(Default).Format(w, r, New(status, msg))
+}
@@ -0,0 +1,123 @@
+package httperrorfmt
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// IDGenerator creates ids for errors that don't carry their own
+type IDGenerator interface {
+	NewID() string
+}
+
+// IDGeneratorFunc adapts a function to IDGenerator
+type IDGeneratorFunc func() string
+
+// NewID calls f()
+func (f IDGeneratorFunc) NewID() string { return f() }
+
+// Sortable id schemes for Features.IDs
+var (
+	// UUIDv7 generates RFC 9562 version 7 UUIDs
+	UUIDv7 IDGenerator = IDGeneratorFunc(newUUIDv7)
+	// ULID generates 26 character Crockford base32 ULIDs
+	ULID IDGenerator = IDGeneratorFunc(newULID)
+	// KSUID generates 27 character base62 KSUIDs
+	KSUID IDGenerator = IDGeneratorFunc(newKSUID)
+)
+
+// newUUIDv7 generates a time ordered RFC 9562 version 7 UUID
+func newUUIDv7() string {
+	var b [16]byte
+	rand.Read(b[:])
+	var ts [8]byte
+	binary.BigEndian.PutUint64(ts[:], uint64(time.Now().UnixMilli()))
+	copy(b[:6], ts[2:])
+	b[6] = b[6]&0x0f | 0x70
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+// newULID generates a ULID: a 48 bit millisecond timestamp followed by 80
+// random bits, in Crockford base32
+func newULID() string {
+	const alphabet = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+	var b [16]byte
+	rand.Read(b[6:])
+	var ts [8]byte
+	binary.BigEndian.PutUint64(ts[:], uint64(time.Now().UnixMilli()))
+	copy(b[:6], ts[2:])
+
+	hi, lo := binary.BigEndian.Uint64(b[:8]), binary.BigEndian.Uint64(b[8:])
+	var out [26]byte
+	for i := len(out) - 1; i >= 0; i-- {
+		out[i] = alphabet[lo&31]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+	return string(out[:])
+}
+
+// ksuidEpoch is the KSUID timestamp origin, 2014-05-13T16:53:20Z
+const ksuidEpoch = 1400000000
+
+// newKSUID generates a KSUID: a 32 bit second timestamp since the KSUID epoch
+// followed by 128 random bits, in base62
+func newKSUID() string {
+	const alphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+	var b [20]byte
+	binary.BigEndian.PutUint32(b[:4], uint32(time.Now().Unix()-ksuidEpoch))
+	rand.Read(b[4:])
+
+	n := new(big.Int).SetBytes(b[:])
+	base := big.NewInt(62)
+	mod := new(big.Int)
+	out := make([]byte, 27)
+	for i := len(out) - 1; i >= 0; i-- {
+		n.DivMod(n, base, mod)
+		out[i] = alphabet[mod.Int64()]
+	}
+	return string(out)
+}
+
+// snowflakeEpoch is the Twitter Snowflake epoch, 2010-11-04T01:42:54.657Z
+const snowflakeEpoch = 1288834974657
+
+// Snowflake returns a generator of 64 bit Snowflake ids for a node between 0
+// and 1023: a 41 bit millisecond timestamp, the node and a 12 bit sequence,
+// rendered in decimal. Each process generating ids needs its own node.
+func Snowflake(node int64) IDGenerator {
+	return &snowflake{node: node & 0x3ff}
+}
+
+// snowflake hands out Snowflake ids for one node
+type snowflake struct {
+	mu       sync.Mutex
+	node     int64
+	last     int64
+	sequence int64
+}
+
+// NewID returns the next id. When the clock goes backwards or the sequence
+// of a millisecond is exhausted, the timestamp runs ahead of the clock
+// instead of waiting for it, so ids stay unique and increasing.
+func (s *snowflake) NewID() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now().UnixMilli() - snowflakeEpoch
+	switch {
+	case now > s.last:
+		s.last, s.sequence = now, 0
+	case s.sequence == 0xfff:
+		s.last, s.sequence = s.last+1, 0
+	default:
+		s.sequence++
+	}
+	return strconv.FormatInt(s.last<<22|s.node<<12|s.sequence, 10)
+}
@@ -0,0 +1,132 @@
+package httperrorfmt
+
+import (
+	"bytes"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// maxInterceptedBody bounds how much of an intercepted body is kept
+const maxInterceptedBody = 64 << 10
+
+// interceptWriter holds back responses that match so they can be re-rendered
+// once the handler returns. Everything else passes straight through.
+type interceptWriter struct {
+	http.ResponseWriter
+	match       func(status int, header http.Header) bool
+	status      int
+	wroteHeader bool
+	intercepted bool
+	body        bytes.Buffer
+}
+
+func (w *interceptWriter) WriteHeader(status int) {
+	if w.wroteHeader {
+		return
+	}
+	if status >= 100 && status < 200 {
+		w.ResponseWriter.WriteHeader(status)
+		return
+	}
+	w.wroteHeader = true
+	w.status = status
+	if w.match(status, w.Header()) {
+		w.intercepted = true
+		return
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *interceptWriter) Write(p []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.intercepted {
+		if room := maxInterceptedBody - w.body.Len(); room > 0 {
+			w.body.Write(p[:min(len(p), room)])
+		}
+		return len(p), nil
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+// Flush forwards to the underlying writer unless the response is held back
+func (w *interceptWriter) Flush() {
+	if w.intercepted {
+		return
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (w *interceptWriter) Unwrap() http.ResponseWriter { return w.ResponseWriter }
+
+// isLegacyError reports whether the headers look like they were set by http.Error
+func isLegacyError(status int, header http.Header) bool {
+	return status >= 400 &&
+		header.Get("Content-Type") == "text/plain; charset=utf-8" &&
+		header.Get("X-Content-Type-Options") == "nosniff"
+}
+
+// LegacyErrors returns middleware that re-renders responses written by
+// http.Error further down the chain through f, using the status and the first
+// line of the body as the message. It lets handlers be migrated one at a time.
+func LegacyErrors(f Formatter) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			iw := &interceptWriter{ResponseWriter: w, match: isLegacyError}
+			next.ServeHTTP(iw, r)
+			if !iw.intercepted {
+				return
+			}
+
+			message, _, _ := strings.Cut(iw.body.String(), "\n")
+			message = strings.TrimSpace(message)
+			if message == "" {
+				message = statusText(iw.status)
+			}
+			w.Header().Del("Content-Type")
+			w.Header().Del("X-Content-Type-Options")
+			OrDefault(f).Format(w, r, New(iw.status, message))
+		})
+	}
+}
+
+// representationHeaders describe the body of the intercepted response and
+// don't apply to the rendered error
+var representationHeaders = []string{
+	"Content-Type", "Content-Encoding", "Content-Disposition",
+	"X-Content-Type-Options", "ETag", "Last-Modified",
+}
+
+// InterceptErrors returns middleware that replaces error responses written
+// further down the chain with a body rendered by f, like nginx error_page. The
+// handler's body is dropped and the status kept, with the status text as the
+// message. Only the listed statuses are intercepted; none means every status
+// of 400 and above. It retrofits consistent error pages onto handlers such as
+// http.FileServer.
+func InterceptErrors(f Formatter, statuses ...int) func(http.Handler) http.Handler {
+	match := func(status int, _ http.Header) bool {
+		if len(statuses) == 0 {
+			return status >= 400
+		}
+		return slices.Contains(statuses, status)
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			iw := &interceptWriter{ResponseWriter: w, match: match}
+			next.ServeHTTP(iw, r)
+			if !iw.intercepted {
+				return
+			}
+
+			for _, name := range representationHeaders {
+				w.Header().Del(name)
+			}
+			OrDefault(f).Format(w, r, New(iw.status, statusText(iw.status)))
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package httperrorfmt
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Extension members set by JSONError
+var (
+	// JSONOffset is the byte offset in the request body where decoding failed
+	JSONOffset = NewExtensionKey[int64]("offset")
+	// JSONExpectedType is the Go type a JSON value couldn't be decoded into
+	JSONExpectedType = NewExtensionKey[string]("expected_type")
+)
+
+// JSONError converts the errors of encoding/json decoding a request body to
+// 400s: syntax errors, values of the wrong type, unknown fields and empty or
+// truncated bodies. The failing field becomes a cause, and the offset and
+// expected type extension members. Other errors give nil, so it can be added
+// to an ErrorMapper:
+//
+//	mapper.Add(httperrorfmt.JSONError)
+func JSONError(err error) HTTPError {
+	if err == nil {
+		return nil
+	}
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &syntaxErr):
+		message := fmt.Sprintf("Malformed JSON at offset %d", syntaxErr.Offset)
+		return Wrap(err, http.StatusBadRequest, "Request body contains malformed JSON").
+			WithCauses(Cause{Reason: "syntax", Message: message}).
+			WithExtensions(JSONOffset.Value(syntaxErr.Offset))
+	case errors.As(err, &typeErr):
+		expected := typeErr.Type.String()
+		message := fmt.Sprintf("Expected %s, got %s", expected, typeErr.Value)
+		return Wrap(err, http.StatusBadRequest, "Request body contains a value of the wrong type").
+			WithCauses(Cause{Reason: "type", Message: message, Field: typeErr.Field}).
+			WithExtensions(JSONOffset.Value(typeErr.Offset), JSONExpectedType.Value(expected))
+	case errors.Is(err, io.EOF):
+		return Wrap(err, http.StatusBadRequest, "Request body is empty")
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return Wrap(err, http.StatusBadRequest, "Request body contains malformed JSON").
+			WithCauses(Cause{Reason: "truncated", Message: "Unexpected end of JSON input"})
+	}
+	// Unknown field errors have no type of their own
+	if field, ok := strings.CutPrefix(err.Error(), `json: unknown field "`); ok {
+		field = strings.TrimSuffix(field, `"`)
+		return Wrap(err, http.StatusBadRequest, "Request body contains an unknown field").
+			WithCauses(Cause{Reason: "unknown_field", Message: fmt.Sprintf("Unknown field %q", field), Field: field})
+	}
+	return nil
+}
+
+// DecodeJSON decodes the request body into v, rejecting unknown fields and
+// trailing data. Decoding errors are converted with JSONError and bodies over
+// the limit of http.MaxBytesReader with FormError; other errors of the body
+// reader are returned unchanged.
+func DecodeJSON(r *http.Request, v any) error {
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		if httpErr := JSONError(err); httpErr != nil {
+			return httpErr
+		}
+		if httpErr := FormError(err); httpErr != nil {
+			return httpErr
+		}
+		return err
+	}
+	offset := dec.InputOffset()
+	if err := dec.Decode(&struct{}{}); !errors.Is(err, io.EOF) {
+		return New(http.StatusBadRequest, "Request body contains more than one JSON value").
+			WithCauses(Cause{Reason: "trailing_data", Message: fmt.Sprintf("Unexpected data after offset %d", offset)}).
+			WithExtensions(JSONOffset.Value(offset))
+	}
+	return nil
+}
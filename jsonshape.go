@@ -0,0 +1,132 @@
+package httperrorfmt
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// JSONShape customizes the body JSONFormatter writes, to match an existing
+// API contract. The zero shape writes the same body as no shape.
+type JSONShape struct {
+	// Fields renames members, keyed by their ErrorResponse name, e.g.
+	// {"error": "message"}. Mapping a member to "" drops it.
+	Fields map[string]string
+	// Envelope nests the body under a key such as "error"
+	Envelope string
+	// EnvelopeList makes the envelope a one element array, as in {"errors": [...]}
+	EnvelopeList bool
+	// Extensions adds the members of errors implementing Extensions() map[string]any.
+	// They never replace built-in members.
+	Extensions bool
+}
+
+// build turns a default response into the shaped body
+func (s *JSONShape) build(response ErrorResponse, err HTTPError) any {
+	body := objectOf(response)
+	shaped := make(orderedObject, 0, len(body))
+	for _, member := range body {
+		if name, ok := s.Fields[member.Name]; ok {
+			if name == "" {
+				continue
+			}
+			member.Name = name
+		}
+		if !shaped.has(member.Name) {
+			shaped = append(shaped, member)
+		}
+	}
+
+	if s.Extensions {
+		var e interface{ Extensions() map[string]any }
+		if errors.As(err, &e) {
+			extensions := e.Extensions()
+			for _, name := range slices.Sorted(maps.Keys(extensions)) {
+				if !shaped.has(name) {
+					shaped = append(shaped, jsonMember{Name: name, Value: extensions[name]})
+				}
+			}
+		}
+	}
+
+	switch {
+	case s.Envelope == "":
+		return shaped
+	case s.EnvelopeList:
+		return orderedObject{{Name: s.Envelope, Value: []any{shaped}}}
+	default:
+		return orderedObject{{Name: s.Envelope, Value: shaped}}
+	}
+}
+
+// jsonMember is one member of an orderedObject
+type jsonMember struct {
+	Name  string
+	Value any
+}
+
+// orderedObject is a JSON object that keeps its members in order
+type orderedObject []jsonMember
+
+// has reports whether the object has a member called name
+func (o orderedObject) has(name string) bool {
+	return slices.ContainsFunc(o, func(m jsonMember) bool { return m.Name == name })
+}
+
+// MarshalJSON renders the members in order
+func (o orderedObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, member := range o {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		name, _ := json.Marshal(member.Name)
+		value, err := json.Marshal(member.Value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(name)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// objectOf converts a struct into an ordered object following its json tags,
+// leaving out empty omitempty members like encoding/json does
+func objectOf(v any) orderedObject {
+	value := reflect.ValueOf(v)
+	typ := value.Type()
+	var object orderedObject
+	for i := range typ.NumField() {
+		name, options, _ := strings.Cut(typ.Field(i).Tag.Get("json"), ",")
+		if name == "-" || !typ.Field(i).IsExported() {
+			continue
+		}
+		if name == "" {
+			name = typ.Field(i).Name
+		}
+		field := value.Field(i)
+		if strings.Contains(options, "omitempty") && isEmptyValue(field) {
+			continue
+		}
+		object = append(object, jsonMember{Name: name, Value: field.Interface()})
+	}
+	return object
+}
+
+// isEmptyValue reports whether encoding/json's omitempty would leave v out
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	default:
+		return v.IsZero()
+	}
+}
@@ -0,0 +1,118 @@
+package httperrorfmt
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+// KubernetesStatusFormatter formats errors as Kubernetes metav1.Status objects
+type KubernetesStatusFormatter struct {
+	PrettyPrint bool
+}
+
+// KubernetesStatus mirrors the metav1.Status object
+type KubernetesStatus struct {
+	Kind       string                   `json:"kind"`
+	APIVersion string                   `json:"apiVersion"`
+	Metadata   struct{}                 `json:"metadata"`
+	Status     string                   `json:"status"`
+	Message    string                   `json:"message,omitempty"`
+	Reason     string                   `json:"reason,omitempty"`
+	Details    *KubernetesStatusDetails `json:"details,omitempty"`
+	Code       int                      `json:"code"`
+}
+
+// KubernetesStatusDetails mirrors the metav1.StatusDetails object
+type KubernetesStatusDetails struct {
+	Name              string  `json:"name,omitempty"`
+	Group             string  `json:"group,omitempty"`
+	Kind              string  `json:"kind,omitempty"`
+	UID               string  `json:"uid,omitempty"`
+	Causes            []Cause `json:"causes,omitempty"`
+	RetryAfterSeconds int     `json:"retryAfterSeconds,omitempty"`
+}
+
+// Format implements Formatter interface for Kubernetes Status responses.
+// Errors implementing KubernetesDetails() *KubernetesStatusDetails provide the
+// details object; causes are taken from Causes() []Cause when not set there.
+func (f *KubernetesStatusFormatter) Format(w http.ResponseWriter, r *http.Request, err HTTPError) {
+	r, err = normalize(r, err)
+	writeHeader(w, r, err, "application/json")
+
+	response := KubernetesStatus{
+		Kind:       "Status",
+		APIVersion: "v1",
+		Status:     "Failure",
+		Message:    publicMessage(err),
+		Reason:     kubernetesReason(err.StatusCode()),
+		Details:    kubernetesDetails(err),
+		Code:       err.StatusCode(),
+	}
+
+	var data []byte
+	if f.PrettyPrint {
+		data, _ = json.MarshalIndent(response, "", "  ")
+	} else {
+		data, _ = json.Marshal(response)
+	}
+
+	w.Write(data)
+}
+
+// kubernetesDetails assembles the details object for an error
+func kubernetesDetails(err HTTPError) *KubernetesStatusDetails {
+	var details KubernetesStatusDetails
+	var d interface {
+		KubernetesDetails() *KubernetesStatusDetails
+	}
+	if errors.As(err, &d) && d.KubernetesDetails() != nil {
+		details = *d.KubernetesDetails()
+	}
+	if details.Causes == nil {
+		details.Causes = causesOf(err)
+	}
+	if details.Name == "" && details.Group == "" && details.Kind == "" && details.UID == "" &&
+		len(details.Causes) == 0 && details.RetryAfterSeconds == 0 {
+		return nil
+	}
+	return &details
+}
+
+// kubernetesReason maps a status code onto a metav1.StatusReason
+func kubernetesReason(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return "BadRequest"
+	case http.StatusUnauthorized:
+		return "Unauthorized"
+	case http.StatusForbidden:
+		return "Forbidden"
+	case http.StatusNotFound:
+		return "NotFound"
+	case http.StatusMethodNotAllowed:
+		return "MethodNotAllowed"
+	case http.StatusNotAcceptable:
+		return "NotAcceptable"
+	case http.StatusConflict:
+		return "Conflict"
+	case http.StatusGone:
+		return "Gone"
+	case http.StatusRequestEntityTooLarge:
+		return "RequestEntityTooLarge"
+	case http.StatusUnsupportedMediaType:
+		return "UnsupportedMediaType"
+	case http.StatusUnprocessableEntity:
+		return "Invalid"
+	case http.StatusTooManyRequests:
+		return "TooManyRequests"
+	case http.StatusInternalServerError:
+		return "InternalError"
+	case http.StatusServiceUnavailable:
+		return "ServiceUnavailable"
+	case http.StatusGatewayTimeout:
+		return "Timeout"
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,92 @@
+package httperrorfmt
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// translationsOf returns the locale to message map of an error, if any. Errors
+// provide one by implementing Translations() map[string]string.
+func translationsOf(err HTTPError) map[string]string {
+	var t interface{ Translations() map[string]string }
+	if errors.As(err, &t) {
+		return t.Translations()
+	}
+	return nil
+}
+
+// localizedError replaces the public message of an error with a translation
+type localizedError struct {
+	HTTPError
+	language string
+	message  string
+}
+
+// Message returns the translated message
+func (e *localizedError) Message() string { return e.message }
+
+// PublicMessage returns the translated message
+func (e *localizedError) PublicMessage() string { return e.message }
+
+// InternalMessage returns the internal message of the original error
+func (e *localizedError) InternalMessage() string { return internalMessage(e.HTTPError) }
+
+// Unwrap returns the original error
+func (e *localizedError) Unwrap() error { return e.HTTPError }
+
+// localize picks the translation of err that best matches the request's
+// Accept-Language header. Errors without a matching translation are returned
+// unchanged.
+func localize(r *http.Request, err HTTPError) HTTPError {
+	if _, done := err.(*localizedError); done {
+		return err
+	}
+	translations := translationsOf(err)
+	if len(translations) == 0 {
+		return err
+	}
+	language, ok := matchLanguage(r.Header.Get("Accept-Language"), translations)
+	if !ok {
+		return err
+	}
+	return &localizedError{HTTPError: err, language: language, message: translations[language]}
+}
+
+// matchLanguage finds the translation for the most preferred language range.
+// A range matches a locale that is equal to it or more specific ("en" matches
+// "en-GB"), and failing that a less specific one ("en-GB" matches "en").
+func matchLanguage(accept string, translations map[string]string) (string, bool) {
+	for _, entry := range parseWeighted(accept) {
+		if entry.Q == 0 || entry.Value == "*" {
+			continue
+		}
+		var fallback string
+		for locale := range translations {
+			tag := strings.ToLower(locale)
+			switch {
+			case tag == entry.Value:
+				return locale, true
+			case strings.HasPrefix(tag, entry.Value+"-"):
+				if fallback == "" || locale < fallback {
+					fallback = locale
+				}
+			case strings.HasPrefix(entry.Value, tag+"-") && fallback == "":
+				fallback = locale
+			}
+		}
+		if fallback != "" {
+			return fallback, true
+		}
+	}
+	return "", false
+}
+
+// contentLanguage returns the language of the message sent for err, if known
+func contentLanguage(err HTTPError) string {
+	var l *localizedError
+	if errors.As(err, &l) {
+		return l.language
+	}
+	return ""
+}
@@ -0,0 +1,48 @@
+package httperrorfmt
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+)
+
+// LegalBlockError is a 451 Unavailable For Legal Reasons error (RFC 7725)
+// naming the entity that implements the block
+type LegalBlockError struct {
+	*errorBase
+	// BlockedBy identifies the entity implementing the block, such as the
+	// operator's legal notices page. It is not the authority that demanded it.
+	BlockedBy string
+}
+
+// NewLegalBlockError creates a 451 error. blockedBy is sent as a
+// Link: <...>; rel="blocked-by" header and rendered in the body.
+func NewLegalBlockError(blockedBy string) *LegalBlockError {
+	return &LegalBlockError{
+		errorBase: New(http.StatusUnavailableForLegalReasons, "This resource is unavailable for legal reasons"),
+		BlockedBy: blockedBy,
+	}
+}
+
+// WithMessage replaces the default message, e.g. to cite the legal demand
+func (e *LegalBlockError) WithMessage(message string) *LegalBlockError {
+	e.public = message
+	return e
+}
+
+// BlockedByURI returns the URI of the entity implementing the block
+func (e *LegalBlockError) BlockedByURI() string { return e.BlockedBy }
+
+// blockedByOf returns the blocking entity of errors implementing
+// BlockedByURI() string, if it is a valid URI
+func blockedByOf(err HTTPError) string {
+	var b interface{ BlockedByURI() string }
+	if !errors.As(err, &b) {
+		return ""
+	}
+	uri := b.BlockedByURI()
+	if u, perr := url.Parse(uri); perr != nil || !u.IsAbs() || headerSafe(uri) != uri {
+		return ""
+	}
+	return uri
+}
@@ -0,0 +1,71 @@
+package httperrorfmt
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// LinkResolver resolves links relevant to an error, keyed by relation
+type LinkResolver interface {
+	ResolveLinks(r *http.Request, err HTTPError) map[string]string
+}
+
+// LinkResolverFunc adapts an ordinary function to a LinkResolver
+type LinkResolverFunc func(r *http.Request, err HTTPError) map[string]string
+
+// ResolveLinks calls f(r, err)
+func (f LinkResolverFunc) ResolveLinks(r *http.Request, err HTTPError) map[string]string {
+	return f(r, err)
+}
+
+// DocsLinks returns a resolver pointing "help" at baseURL and "describes" at a
+// page below it named after the error code, or the status code when there is none
+func DocsLinks(baseURL string) LinkResolver {
+	baseURL = strings.TrimSuffix(baseURL, "/")
+	return LinkResolverFunc(func(r *http.Request, err HTTPError) map[string]string {
+		page := errorCode(err)
+		if page == "" {
+			page = strconv.Itoa(err.StatusCode())
+		}
+		return map[string]string{
+			"help":      baseURL,
+			"describes": baseURL + "/" + url.PathEscape(page),
+		}
+	})
+}
+
+// DocsLinkResolver maps errors to their documentation. Set it as
+// Features.Docs to fill help_url for errors without a DocURL() of their own,
+// or use it as the LinkResolver of link-based formatters.
+type DocsLinkResolver struct {
+	// Codes maps application error codes to documentation URLs
+	Codes map[string]string
+	// BaseURL documents codes missing from Codes at BaseURL/<code>. Empty
+	// leaves them undocumented.
+	BaseURL string
+}
+
+// DocURL returns the documentation URL for err, or ""
+func (d *DocsLinkResolver) DocURL(err HTTPError) string {
+	code := errorCode(err)
+	if code == "" {
+		return ""
+	}
+	if u, ok := d.Codes[code]; ok {
+		return u
+	}
+	if d.BaseURL != "" {
+		return strings.TrimSuffix(d.BaseURL, "/") + "/" + url.PathEscape(code)
+	}
+	return ""
+}
+
+// ResolveLinks implements LinkResolver with a "help" link
+func (d *DocsLinkResolver) ResolveLinks(r *http.Request, err HTTPError) map[string]string {
+	if u := d.DocURL(err); u != "" {
+		return map[string]string{"help": u}
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package httperrorfmt
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Lint rule names reported in Problem.Rule
+const (
+	RuleServerBlamesClient = "server-blames-client"
+	RuleClientErrorStack   = "client-error-stack"
+	RulePanicMessage       = "panic-message"
+	RuleFilePath           = "file-path"
+)
+
+// Problem is a suspicious trait of an error found by Lint
+type Problem struct {
+	Rule    string
+	Message string
+}
+
+// String returns the rule and message
+func (p Problem) String() string { return p.Rule + ": " + p.Message }
+
+// blamingPhrases suggest a message holds the client responsible
+var blamingPhrases = []string{
+	"invalid", "you must", "you need", "your request", "not allowed",
+	"missing", "malformed", "bad request", "required",
+}
+
+// filePathPattern matches source locations and absolute paths
+var filePathPattern = regexp.MustCompile(`\w+\.go(:\d+)?\b|(^|[\s"'(=])/(home|root|usr|var|etc|tmp|opt|srv|app|src)/|\b[A-Za-z]:\\`)
+
+// Lint flags errors whose public side looks wrong: server errors that blame
+// the client, client errors carrying stack traces and messages that leak
+// panics or file paths. It is meant for tests and for sampling in production.
+func Lint(err HTTPError) []Problem {
+	if err == nil || isNilPointer(err) {
+		return nil
+	}
+	var problems []Problem
+	status := err.StatusCode()
+	message := publicMessage(err)
+	lower := strings.ToLower(message)
+
+	if status >= 500 {
+		for _, phrase := range blamingPhrases {
+			if strings.Contains(lower, phrase) {
+				problems = append(problems, Problem{
+					Rule:    RuleServerBlamesClient,
+					Message: fmt.Sprintf("%d response blames the client (%q); use a 4xx status", status, phrase),
+				})
+				break
+			}
+		}
+	}
+	if status >= 400 && status < 500 && stackOf(err) != "" {
+		problems = append(problems, Problem{
+			Rule:    RuleClientErrorStack,
+			Message: "client error carries a stack trace",
+		})
+	}
+	if strings.Contains(lower, "panic:") || strings.Contains(lower, "goroutine ") {
+		problems = append(problems, Problem{
+			Rule:    RulePanicMessage,
+			Message: "message contains panic output",
+		})
+	}
+	if filePathPattern.MatchString(message) {
+		problems = append(problems, Problem{
+			Rule:    RuleFilePath,
+			Message: "message contains a file path",
+		})
+	}
+	return problems
+}
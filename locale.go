@@ -0,0 +1,86 @@
+package httperrorfmt
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Localizer formats numbers, dates and durations for a language, so retry
+// hints and other values in error pages read naturally in every language.
+// Languages are BCP 47 tags such as "de" or "en-GB"; an empty language means
+// the Localizer's own default. The localeerr module provides one based on
+// golang.org/x/text.
+type Localizer interface {
+	FormatNumber(language string, n float64) string
+	FormatDate(language string, t time.Time) string
+	FormatDuration(language string, d time.Duration) string
+}
+
+// responseLanguage returns the language of the response to r: the language of
+// the translation sent for err, or else the client's most preferred language
+func responseLanguage(r *http.Request, err HTTPError) string {
+	if language := contentLanguage(err); language != "" {
+		return language
+	}
+	for _, entry := range parseWeighted(r.Header.Get("Accept-Language")) {
+		if entry.Q > 0 && entry.Value != "*" {
+			return entry.Value
+		}
+	}
+	return ""
+}
+
+// Number formats n, an integer or floating point number, for the language of
+// the response. Without a Localizer it is written as Go formats it.
+func (d TemplateData) Number(n any) string {
+	var f float64
+	switch v := n.(type) {
+	case int:
+		f = float64(v)
+	case int64:
+		f = float64(v)
+	case int32:
+		f = float64(v)
+	case uint:
+		f = float64(v)
+	case uint64:
+		f = float64(v)
+	case uint32:
+		f = float64(v)
+	case float64:
+		f = v
+	case float32:
+		f = float64(v)
+	default:
+		return fmt.Sprint(n)
+	}
+	if d.localizer == nil {
+		return fmt.Sprint(n)
+	}
+	return d.localizer.FormatNumber(d.Language, f)
+}
+
+// Date formats t for the language of the response. Without a Localizer it is
+// written as an RFC 3339 date.
+func (d TemplateData) Date(t time.Time) string {
+	if d.localizer == nil {
+		return t.Format(time.DateOnly)
+	}
+	return d.localizer.FormatDate(d.Language, t)
+}
+
+// Duration formats dur for the language of the response, e.g. "2 minutes".
+// Without a Localizer it is written in seconds.
+func (d TemplateData) Duration(dur time.Duration) string {
+	if d.localizer == nil {
+		return strconv.FormatInt(int64((dur+time.Second-1)/time.Second), 10) + " seconds"
+	}
+	return d.localizer.FormatDuration(d.Language, dur)
+}
+
+// RetryIn formats RetryAfter with Duration, e.g. "2 minutes"
+func (d TemplateData) RetryIn() string {
+	return d.Duration(time.Duration(d.RetryAfter) * time.Second)
+}
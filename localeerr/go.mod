@@ -0,0 +1,7 @@
+module github.com/perbu/httperrorfmt/localeerr
+
+go 1.26.0
+
+replace github.com/perbu/httperrorfmt => ../
+
+require golang.org/x/text v0.42.0
@@ -0,0 +1,208 @@
+// Package localeerr provides an httperrorfmt.Localizer based on
+// golang.org/x/text, so numbers, dates and retry hints in error pages follow
+// the conventions of the response language:
+//
+//	negotiator.SetFeatures(httperrorfmt.Features{Localizer: localeerr.New()})
+package localeerr
+
+import (
+	"fmt"
+	"time"
+
+	"golang.org/x/text/feature/plural"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+	"golang.org/x/text/number"
+)
+
+// units holds the duration units of a language by plural form. Each pattern
+// takes the formatted count.
+type units struct {
+	day, hour, minute, second map[plural.Form]string
+}
+
+// unitTables are the languages with localized durations. The first one is
+// the fallback for other languages.
+var unitTables = []struct {
+	tag   language.Tag
+	units units
+}{
+	{language.English, units{
+		day:    map[plural.Form]string{plural.One: "%s day", plural.Other: "%s days"},
+		hour:   map[plural.Form]string{plural.One: "%s hour", plural.Other: "%s hours"},
+		minute: map[plural.Form]string{plural.One: "%s minute", plural.Other: "%s minutes"},
+		second: map[plural.Form]string{plural.One: "%s second", plural.Other: "%s seconds"},
+	}},
+	{language.German, units{
+		day:    map[plural.Form]string{plural.One: "%s Tag", plural.Other: "%s Tage"},
+		hour:   map[plural.Form]string{plural.One: "%s Stunde", plural.Other: "%s Stunden"},
+		minute: map[plural.Form]string{plural.One: "%s Minute", plural.Other: "%s Minuten"},
+		second: map[plural.Form]string{plural.One: "%s Sekunde", plural.Other: "%s Sekunden"},
+	}},
+	{language.French, units{
+		day:    map[plural.Form]string{plural.One: "%s jour", plural.Other: "%s jours"},
+		hour:   map[plural.Form]string{plural.One: "%s heure", plural.Other: "%s heures"},
+		minute: map[plural.Form]string{plural.One: "%s minute", plural.Other: "%s minutes"},
+		second: map[plural.Form]string{plural.One: "%s seconde", plural.Other: "%s secondes"},
+	}},
+	{language.Spanish, units{
+		day:    map[plural.Form]string{plural.One: "%s día", plural.Other: "%s días"},
+		hour:   map[plural.Form]string{plural.One: "%s hora", plural.Other: "%s horas"},
+		minute: map[plural.Form]string{plural.One: "%s minuto", plural.Other: "%s minutos"},
+		second: map[plural.Form]string{plural.One: "%s segundo", plural.Other: "%s segundos"},
+	}},
+	{language.Italian, units{
+		day:    map[plural.Form]string{plural.One: "%s giorno", plural.Other: "%s giorni"},
+		hour:   map[plural.Form]string{plural.One: "%s ora", plural.Other: "%s ore"},
+		minute: map[plural.Form]string{plural.One: "%s minuto", plural.Other: "%s minuti"},
+		second: map[plural.Form]string{plural.One: "%s secondo", plural.Other: "%s secondi"},
+	}},
+	{language.Portuguese, units{
+		day:    map[plural.Form]string{plural.One: "%s dia", plural.Other: "%s dias"},
+		hour:   map[plural.Form]string{plural.One: "%s hora", plural.Other: "%s horas"},
+		minute: map[plural.Form]string{plural.One: "%s minuto", plural.Other: "%s minutos"},
+		second: map[plural.Form]string{plural.One: "%s segundo", plural.Other: "%s segundos"},
+	}},
+	{language.Dutch, units{
+		day:    map[plural.Form]string{plural.One: "%s dag", plural.Other: "%s dagen"},
+		hour:   map[plural.Form]string{plural.One: "%s uur", plural.Other: "%s uur"},
+		minute: map[plural.Form]string{plural.One: "%s minuut", plural.Other: "%s minuten"},
+		second: map[plural.Form]string{plural.One: "%s seconde", plural.Other: "%s seconden"},
+	}},
+	{language.MustParse("nb"), units{
+		day:    map[plural.Form]string{plural.One: "%s dag", plural.Other: "%s dager"},
+		hour:   map[plural.Form]string{plural.One: "%s time", plural.Other: "%s timer"},
+		minute: map[plural.Form]string{plural.One: "%s minutt", plural.Other: "%s minutter"},
+		second: map[plural.Form]string{plural.One: "%s sekund", plural.Other: "%s sekunder"},
+	}},
+	{language.Swedish, units{
+		day:    map[plural.Form]string{plural.One: "%s dag", plural.Other: "%s dagar"},
+		hour:   map[plural.Form]string{plural.One: "%s timme", plural.Other: "%s timmar"},
+		minute: map[plural.Form]string{plural.One: "%s minut", plural.Other: "%s minuter"},
+		second: map[plural.Form]string{plural.One: "%s sekund", plural.Other: "%s sekunder"},
+	}},
+	{language.Danish, units{
+		day:    map[plural.Form]string{plural.One: "%s dag", plural.Other: "%s dage"},
+		hour:   map[plural.Form]string{plural.One: "%s time", plural.Other: "%s timer"},
+		minute: map[plural.Form]string{plural.One: "%s minut", plural.Other: "%s minutter"},
+		second: map[plural.Form]string{plural.One: "%s sekund", plural.Other: "%s sekunder"},
+	}},
+	{language.Polish, units{
+		day:    map[plural.Form]string{plural.One: "%s dzień", plural.Few: "%s dni", plural.Many: "%s dni", plural.Other: "%s dnia"},
+		hour:   map[plural.Form]string{plural.One: "%s godzinę", plural.Few: "%s godziny", plural.Many: "%s godzin", plural.Other: "%s godziny"},
+		minute: map[plural.Form]string{plural.One: "%s minutę", plural.Few: "%s minuty", plural.Many: "%s minut", plural.Other: "%s minuty"},
+		second: map[plural.Form]string{plural.One: "%s sekundę", plural.Few: "%s sekundy", plural.Many: "%s sekund", plural.Other: "%s sekundy"},
+	}},
+	{language.Japanese, units{
+		day:    map[plural.Form]string{plural.Other: "%s日"},
+		hour:   map[plural.Form]string{plural.Other: "%s時間"},
+		minute: map[plural.Form]string{plural.Other: "%s分"},
+		second: map[plural.Form]string{plural.Other: "%s秒"},
+	}},
+	{language.Chinese, units{
+		day:    map[plural.Form]string{plural.Other: "%s天"},
+		hour:   map[plural.Form]string{plural.Other: "%s小时"},
+		minute: map[plural.Form]string{plural.Other: "%s分钟"},
+		second: map[plural.Form]string{plural.Other: "%s秒"},
+	}},
+	{language.TraditionalChinese, units{
+		day:    map[plural.Form]string{plural.Other: "%s天"},
+		hour:   map[plural.Form]string{plural.Other: "%s小時"},
+		minute: map[plural.Form]string{plural.Other: "%s分鐘"},
+		second: map[plural.Form]string{plural.Other: "%s秒"},
+	}},
+}
+
+// dateLayouts are the numeric date layouts of languages, by base language.
+// Languages without one use ISO 8601.
+var dateLayouts = map[string]string{
+	"en": "02/01/2006",
+	"fr": "02/01/2006",
+	"es": "02/01/2006",
+	"it": "02/01/2006",
+	"pt": "02/01/2006",
+	"nl": "02-01-2006",
+	"de": "02.01.2006",
+	"nb": "02.01.2006",
+	"no": "02.01.2006",
+	"da": "02.01.2006",
+	"pl": "02.01.2006",
+	"ja": "2006/01/02",
+	"zh": "2006/01/02",
+}
+
+// Localizer implements httperrorfmt.Localizer. Numbers use the separators of
+// the language as x/text knows them; durations are localized for English,
+// German, French, Spanish, Italian, Portuguese, Dutch, Norwegian, Swedish,
+// Danish, Polish, Japanese and Chinese, and written in English otherwise.
+type Localizer struct {
+	matcher language.Matcher
+}
+
+// New creates a Localizer
+func New() *Localizer {
+	tags := make([]language.Tag, len(unitTables))
+	for i, table := range unitTables {
+		tags[i] = table.tag
+	}
+	return &Localizer{matcher: language.NewMatcher(tags)}
+}
+
+// FormatNumber implements httperrorfmt.Localizer
+func (l *Localizer) FormatNumber(lang string, n float64) string {
+	return message.NewPrinter(parse(lang)).Sprint(number.Decimal(n))
+}
+
+// FormatDate implements httperrorfmt.Localizer. Dates are numeric, in the
+// order of the language; American English puts the month first.
+func (l *Localizer) FormatDate(lang string, t time.Time) string {
+	tag := parse(lang)
+	base, _ := tag.Base()
+	if region, _ := tag.Region(); base.String() == "en" && region.String() == "US" {
+		return t.Format("01/02/2006")
+	}
+	if layout, ok := dateLayouts[base.String()]; ok {
+		return t.Format(layout)
+	}
+	return t.Format(time.DateOnly)
+}
+
+// FormatDuration implements httperrorfmt.Localizer. Durations are rounded up
+// to whole days, hours, minutes or seconds, whichever is the largest unit
+// that fits, so a retry hint never asks clients to come back too early.
+func (l *Localizer) FormatDuration(lang string, d time.Duration) string {
+	tag := parse(lang)
+	_, index, confidence := l.matcher.Match(tag)
+	if confidence == language.No {
+		index = 0
+	}
+	u := unitTables[index].units
+
+	patterns, unit := u.second, time.Second
+	switch {
+	case d >= 24*time.Hour:
+		patterns, unit = u.day, 24*time.Hour
+	case d >= time.Hour:
+		patterns, unit = u.hour, time.Hour
+	case d >= time.Minute:
+		patterns, unit = u.minute, time.Minute
+	}
+	count := int((max(d, 0) + unit - 1) / unit)
+
+	pluralTag := unitTables[index].tag
+	form := plural.Cardinal.MatchPlural(pluralTag, count, 0, 0, 0, 0)
+	pattern, ok := patterns[form]
+	if !ok {
+		pattern = patterns[plural.Other]
+	}
+	return fmt.Sprintf(pattern, message.NewPrinter(tag).Sprint(count))
+}
+
+// parse parses a BCP 47 tag, falling back to English
+func parse(lang string) language.Tag {
+	tag, err := language.Parse(lang)
+	if err != nil || tag == language.Und {
+		return language.English
+	}
+	return tag
+}
@@ -0,0 +1,97 @@
+package httperrorfmt
+
+import (
+	"context"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+)
+
+// LoggingFormatter logs every error it formats with log/slog, after delegating
+// to Formatter. Its Log method is a Hook, so it can also be added to a
+// negotiator with OnError.
+type LoggingFormatter struct {
+	Formatter Formatter
+	// Logger receives the records. Nil means slog.Default().
+	Logger *slog.Logger
+	// Level maps a status to a log level. Nil logs 5xx as errors, 4xx as
+	// warnings and anything else as info.
+	Level func(status int) slog.Level
+}
+
+// Format implements Formatter interface by delegating and then logging the error
+func (f *LoggingFormatter) Format(w http.ResponseWriter, r *http.Request, err HTTPError) {
+	r, err = normalize(r, err)
+	OrDefault(f.Formatter).Format(w, r, err)
+	f.Log(r, err)
+}
+
+// Log writes a record for err with the status, error code, method, path,
+// client IP, request id and, for requests passed through Timed, the duration
+func (f *LoggingFormatter) Log(r *http.Request, err HTTPError) {
+	logger := f.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+	level := defaultLevel
+	if f.Level != nil {
+		level = f.Level
+	}
+
+	status := err.StatusCode()
+	attrs := []slog.Attr{
+		slog.Int("status", status),
+		slog.String("error", internalMessage(err)),
+		slog.String("method", r.Method),
+	}
+	if r.URL != nil {
+		attrs = append(attrs, slog.String("path", r.URL.Path))
+	}
+	if code := internalErrorCode(err); code != "" {
+		attrs = append(attrs, slog.String("code", code))
+	}
+	if ip := clientIP(r); ip != "" {
+		attrs = append(attrs, slog.String("client_ip", ip))
+	}
+	if id, ok := requestIDFrom(r); ok {
+		attrs = append(attrs, slog.String("request_id", id.id))
+	} else if id := headerSafe(r.Header.Get("X-Request-ID")); id != "" {
+		attrs = append(attrs, slog.String("request_id", id))
+	}
+	if start, ok := r.Context().Value(startKey{}).(time.Time); ok {
+		attrs = append(attrs, slog.Duration("duration", time.Since(start)))
+	}
+	logger.LogAttrs(r.Context(), level(status), "http error", attrs...)
+}
+
+// defaultLevel logs 5xx as errors and 4xx as warnings
+func defaultLevel(status int) slog.Level {
+	switch {
+	case status >= 500:
+		return slog.LevelError
+	case status >= 400:
+		return slog.LevelWarn
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// clientIP returns the host part of the request's remote address
+func clientIP(r *http.Request) string {
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	return r.RemoteAddr
+}
+
+// startKey is the request context key for the time Timed saw the request
+type startKey struct{}
+
+// Timed records when requests arrive, so LoggingFormatter can log how long
+// they took until the error was formatted
+func Timed(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), startKey{}, time.Now())))
+	})
+}
@@ -0,0 +1,61 @@
+package httperrorfmt
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+)
+
+// LongPollFormatter keeps long-poll clients, which often treat any non-2xx
+// response as a network failure and retry at once, from hammering a server
+// in trouble. Errors formatted after StartPoll are sent with status 200 and
+// the real status in a header. Errors before the poll started, such as bad
+// parameters or failed authentication, keep their status.
+type LongPollFormatter struct {
+	Formatter Formatter
+	// StatusHeader carries the real status. Empty means X-Status.
+	StatusHeader string
+	// Envelope rewrites the converted response, e.g. to wrap the body in the
+	// envelope the client expects. Nil sends the formatted body as is.
+	Envelope PostProcessor
+}
+
+// pollKey is the request context key marking a started poll
+type pollKey struct{}
+
+// StartPoll returns a shallow copy of r marking that the long-poll has started
+// waiting. Format errors with the returned request to have them converted.
+func StartPoll(r *http.Request) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), pollKey{}, true))
+}
+
+// PollStarted reports whether StartPoll marked r
+func PollStarted(r *http.Request) bool {
+	started, _ := r.Context().Value(pollKey{}).(bool)
+	return started
+}
+
+// Format implements Formatter interface by converting errors of started polls
+// to 200 responses
+func (f *LongPollFormatter) Format(w http.ResponseWriter, r *http.Request, err HTTPError) {
+	r, err = normalize(r, err)
+	if !PollStarted(r) {
+		OrDefault(f.Formatter).Format(w, r, err)
+		return
+	}
+
+	buf := newResponseBuffer(w.Header())
+	OrDefault(f.Formatter).Format(buf, r, err)
+	resp := buf.response()
+
+	name := f.StatusHeader
+	if name == "" {
+		name = "X-Status"
+	}
+	resp.Header.Set(name, strconv.Itoa(resp.Status))
+	resp.Status = http.StatusOK
+	if f.Envelope != nil {
+		f.Envelope.PostProcess(r, err, resp)
+	}
+	writeResponse(w, resp)
+}
@@ -0,0 +1,54 @@
+package httperrorfmt
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+)
+
+// Mapping turns a plain error into an HTTPError, or returns nil when it
+// doesn't recognize the error
+type Mapping func(err error) HTTPError
+
+// ErrorMapper turns the errors handlers return into HTTPErrors. Errors that
+// already are HTTPErrors are kept; others go through the mappings in the order
+// they were added, and errors no mapping recognizes become a 500 that keeps
+// the error for logs only. A nil ErrorMapper does the same without mappings.
+type ErrorMapper struct {
+	mu       sync.RWMutex
+	mappings []Mapping
+}
+
+// NewErrorMapper creates a mapper with the given mappings
+func NewErrorMapper(mappings ...Mapping) *ErrorMapper {
+	return (&ErrorMapper{}).Add(mappings...)
+}
+
+// Add appends mappings, tried after those added before
+func (m *ErrorMapper) Add(mappings ...Mapping) *ErrorMapper {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.mappings = append(m.mappings, mappings...)
+	return m
+}
+
+// Map converts err to an HTTPError. A nil error gives nil.
+func (m *ErrorMapper) Map(err error) HTTPError {
+	if err == nil {
+		return nil
+	}
+	var httpErr HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr
+	}
+	if m != nil {
+		m.mu.RLock()
+		defer m.mu.RUnlock()
+		for _, mapping := range m.mappings {
+			if mapped := mapping(err); mapped != nil {
+				return mapped
+			}
+		}
+	}
+	return Wrap(err, http.StatusInternalServerError, "Internal server error")
+}
@@ -0,0 +1,39 @@
+package httperrorfmt
+
+import "errors"
+
+// plainMessage returns the plain-language variant of an error message, if any.
+// Errors provide one by implementing PlainMessage() string.
+func plainMessage(err HTTPError) (string, bool) {
+	var p interface{ PlainMessage() string }
+	if errors.As(err, &p) && p.PlainMessage() != "" {
+		return p.PlainMessage(), true
+	}
+	return "", false
+}
+
+// SplitMessageError is implemented by errors that keep the message shown to
+// clients apart from the one meant for logs, debugging and observers
+type SplitMessageError interface {
+	HTTPError
+	PublicMessage() string
+	InternalMessage() string
+}
+
+// publicMessage returns the message that is safe to send to clients
+func publicMessage(err HTTPError) string {
+	var s SplitMessageError
+	if errors.As(err, &s) {
+		return s.PublicMessage()
+	}
+	return err.Message()
+}
+
+// internalMessage returns the message meant for logs and debugging
+func internalMessage(err HTTPError) string {
+	var s SplitMessageError
+	if errors.As(err, &s) {
+		return s.InternalMessage()
+	}
+	return err.Error()
+}
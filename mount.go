@@ -0,0 +1,41 @@
+package httperrorfmt
+
+import (
+	"maps"
+	"slices"
+)
+
+// Mount creates a negotiator for requests whose path starts with prefix, such
+// as "/v1/", and binds it with Prefix. The mounted negotiator starts out as a
+// copy of cn: its formatters, aliases, default, features, Vary, strictness,
+// extensions, trusted proxies, browser detection, tracing, gRPC answers, error
+// store, redactor and disclosure policy. Register only what differs on it.
+// Post-processors and hooks are not copied, since those of cn already run for
+// mounted requests. Configure cn before mounting; later changes don't reach
+// mounted negotiators.
+func (cn *ContentNegotiator) Mount(prefix string) *ContentNegotiator {
+	mounted := &ContentNegotiator{
+		formatters:     maps.Clone(cn.formatters),
+		aliases:        maps.Clone(cn.aliases),
+		defaults:       cn.defaults,
+		features:       cn.features,
+		extensions:     cn.extensions,
+		vary:           slices.Clone(cn.vary),
+		strict:         cn.strict,
+		tracing:        cn.tracing,
+		grpc:           cn.grpc,
+		detectBrowsers: cn.detectBrowsers,
+		trustedProxies: slices.Clone(cn.trustedProxies),
+		store:          cn.store,
+		redactor:       cn.redactor,
+		disclosure:     cn.disclosure,
+	}
+	if mounted.formatters == nil {
+		mounted.formatters = make(map[string]Formatter)
+	}
+	if mounted.aliases == nil {
+		mounted.aliases = make(map[string]string)
+	}
+	cn.Prefix(prefix, mounted)
+	return mounted
+}
@@ -0,0 +1,70 @@
+package httperrorfmt
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/http"
+)
+
+// MultiStatusFormatter formats errors as WebDAV DAV:multistatus documents.
+// Batch errors get one response element per item; other errors a single one
+// for the requested resource.
+type MultiStatusFormatter struct{}
+
+// davMultiStatus represents a DAV:multistatus element
+type davMultiStatus struct {
+	XMLName     xml.Name      `xml:"DAV: multistatus"`
+	Responses   []davResponse `xml:"response"`
+	Description string        `xml:"responsedescription,omitempty"`
+}
+
+// davResponse represents a DAV:response element
+type davResponse struct {
+	Href        string `xml:"href"`
+	Status      string `xml:"status"`
+	Description string `xml:"responsedescription,omitempty"`
+}
+
+// Format implements Formatter interface for WebDAV multistatus responses
+func (f *MultiStatusFormatter) Format(w http.ResponseWriter, r *http.Request, err HTTPError) {
+	r, err = normalize(r, err)
+	writeHeader(w, r, err, "application/xml; charset=utf-8")
+
+	href := "/"
+	if r.URL != nil {
+		href = r.URL.EscapedPath()
+	}
+
+	doc := davMultiStatus{Description: publicMessage(err)}
+	if items, ok := batchItemsOf(err); ok {
+		for _, item := range items {
+			resource := item.Resource
+			if resource == "" {
+				resource = href
+			}
+			doc.Responses = append(doc.Responses, davResponse{
+				Href:        resource,
+				Status:      davStatus(item.Status),
+				Description: item.Message,
+			})
+		}
+	} else {
+		doc.Description = ""
+		doc.Responses = []davResponse{{
+			Href:        href,
+			Status:      davStatus(err.StatusCode()),
+			Description: publicMessage(err),
+		}}
+	}
+
+	w.Write([]byte(xml.Header))
+
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "    ")
+	encoder.Encode(doc)
+}
+
+// davStatus renders a status code as a DAV:status line
+func davStatus(status int) string {
+	return fmt.Sprintf("HTTP/1.1 %d %s", status, statusText(status))
+}
@@ -0,0 +1,72 @@
+package httperrorfmt
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Trailer names StreamError reports the error in
+const (
+	TrailerErrorStatus  = "X-Error-Status"
+	TrailerErrorMessage = "X-Error-Message"
+)
+
+// NDJSONFormatter formats errors as a single newline-delimited JSON event
+type NDJSONFormatter struct{}
+
+// NDJSONErrorEvent is the record emitted for an error
+type NDJSONErrorEvent struct {
+	Type   string `json:"type"`
+	Error  string `json:"error"`
+	Status int    `json:"status"`
+	Code   string `json:"code,omitempty"`
+}
+
+// Format implements Formatter interface for NDJSON responses
+func (f *NDJSONFormatter) Format(w http.ResponseWriter, r *http.Request, err HTTPError) {
+	r, err = normalize(r, err)
+	writeHeader(w, r, err, "application/x-ndjson")
+	writeNDJSONEvent(w, err)
+}
+
+// StreamError switches an NDJSON stream whose headers were already sent into error
+// mode: the error is appended as a final event, reported in the X-Error-Status and
+// X-Error-Message trailers, and the stream is flushed. The handler should stop
+// writing afterwards.
+func StreamError(w http.ResponseWriter, err HTTPError) error {
+	w.Header().Set(http.TrailerPrefix+TrailerErrorStatus, strconv.Itoa(err.StatusCode()))
+	w.Header().Set(http.TrailerPrefix+TrailerErrorMessage, headerSafe(publicMessage(err)))
+
+	if werr := writeNDJSONEvent(w, err); werr != nil {
+		return werr
+	}
+	if ferr := http.NewResponseController(w).Flush(); ferr != nil && !errors.Is(ferr, http.ErrNotSupported) {
+		return ferr
+	}
+	return nil
+}
+
+// writeNDJSONEvent writes the error as one JSON line
+func writeNDJSONEvent(w http.ResponseWriter, err HTTPError) error {
+	data, _ := json.Marshal(NDJSONErrorEvent{
+		Type:   "error",
+		Error:  publicMessage(err),
+		Status: err.StatusCode(),
+		Code:   statusText(err.StatusCode()),
+	})
+	_, werr := w.Write(append(data, '\n'))
+	return werr
+}
+
+// headerSafe strips characters that can't appear in a header value
+func headerSafe(s string) string {
+	return strings.Map(func(c rune) rune {
+		if c < 0x20 && c != '\t' || c == 0x7f {
+			return -1
+		}
+		return c
+	}, s)
+}
@@ -0,0 +1,85 @@
+package httperrorfmt
+
+import (
+	"crypto/tls"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"syscall"
+)
+
+// WithTransient marks the error as transient: the same request may succeed
+// when retried later
+func (e *Error) WithTransient(transient bool) *Error {
+	e.transient = &transient
+	return e
+}
+
+// Transient reports whether the error was marked as transient, or else
+// whether the wrapped error is
+func (e *Error) Transient() bool {
+	if e.transient == nil {
+		return IsTransient(e.err)
+	}
+	return *e.transient
+}
+
+// IsTransient reports whether err, or an error it wraps, implements
+// Transient() bool and reports true
+func IsTransient(err error) bool {
+	var t interface{ Transient() bool }
+	return errors.As(err, &t) && t.Transient()
+}
+
+// NetworkError classifies network and I/O errors, such as those of calls to
+// other services:
+//
+//   - timeouts give a 504
+//   - refused connections give a 503
+//   - reset connections and failed dials give a 502
+//   - unknown hosts and failed TLS handshakes give a 502
+//   - a truncated request body, io.ErrUnexpectedEOF, gives a 400
+//
+// Timeouts, refused and reset connections and failed dials are transient;
+// the others are permanent. The error is kept for logs. Other errors give
+// nil, so it can be added to an ErrorMapper:
+//
+//	mapper.Add(httperrorfmt.NetworkError)
+func NetworkError(err error) HTTPError {
+	if e := networkError(err); e != nil {
+		return e
+	}
+	return nil
+}
+
+// networkError is NetworkError returning *Error, or nil when err isn't a
+// network or I/O error
+func networkError(err error) *Error {
+	if err == nil {
+		return nil
+	}
+	var netErr net.Error
+	var dnsErr *net.DNSError
+	var opErr *net.OpError
+	var certErr *tls.CertificateVerificationError
+	var alertErr tls.AlertError
+	var recordErr tls.RecordHeaderError
+	switch {
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return Wrap(err, http.StatusGatewayTimeout, "The upstream service did not respond in time").WithTransient(true)
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return Wrap(err, http.StatusServiceUnavailable, "The upstream service is unavailable").WithTransient(true)
+	case errors.Is(err, syscall.ECONNRESET), errors.Is(err, syscall.EPIPE), errors.Is(err, net.ErrClosed):
+		return Wrap(err, http.StatusBadGateway, "The connection to the upstream service was lost").WithTransient(true)
+	case errors.As(err, &dnsErr):
+		return Wrap(err, http.StatusBadGateway, "The upstream service is unreachable").WithTransient(dnsErr.IsTemporary)
+	case errors.As(err, &certErr), errors.As(err, &alertErr), errors.As(err, &recordErr):
+		return Wrap(err, http.StatusBadGateway, "No secure connection to the upstream service could be established")
+	case errors.As(err, &opErr) && opErr.Op == "dial":
+		return Wrap(err, http.StatusBadGateway, "The upstream service is unreachable").WithTransient(true)
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return Wrap(err, http.StatusBadRequest, "Request body is truncated")
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package httperrorfmt
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// MisuseHandler is called when an entry point gets arguments it can't use as
+// given, such as a nil error, a nil request or an invalid status code.
+// Formatting goes ahead with safe replacements either way; MisuseHandler only
+// makes the bug visible. Failures to save errors to an ErrorStore are reported
+// here too. It is separate from the hooks of ContentNegotiator.OnError, which
+// see the errors that are formatted.
+var MisuseHandler func(r *http.Request, err error)
+
+// normalize replaces unusable Format arguments: a nil request becomes a bare
+// GET /, a nil error becomes a 500 and an invalid or informational status code
+// is sent as 500.
+// The error is then localized for the request.
+func normalize(r *http.Request, err HTTPError) (*http.Request, HTTPError) {
+	if r == nil {
+		r, _ = http.NewRequest(http.MethodGet, "/", nil)
+		reportMisuse(r, errors.New("httperrorfmt: nil request"))
+	}
+	if err == nil || isNilPointer(err) {
+		reportMisuse(r, errors.New("httperrorfmt: nil error"))
+		return r, New(http.StatusInternalServerError, statusText(http.StatusInternalServerError)).
+			WithInternal("httperrorfmt: nil error")
+	}
+	if status := err.StatusCode(); status < 200 || status > 999 {
+		reportMisuse(r, fmt.Errorf("httperrorfmt: invalid status code %d: %w", status, err))
+		err = withStatus(err, http.StatusInternalServerError)
+	}
+	return r, localize(r, err)
+}
+
+// reportMisuse passes a misuse problem to MisuseHandler when set
+func reportMisuse(r *http.Request, err error) {
+	if MisuseHandler != nil {
+		MisuseHandler(r, err)
+	}
+}
+
+// OrDefault returns f, falling back to Default and then to plain text when
+// either is nil or a typed nil pointer. Framework adapters use it for their
+// optional Formatter fields.
+func OrDefault(f Formatter) Formatter {
+	switch {
+	case f != nil && !isNilPointer(f):
+		return f
+	case Default != nil && !isNilPointer(Default):
+		return Default
+	default:
+		return &TextFormatter{}
+	}
+}
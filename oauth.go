@@ -0,0 +1,136 @@
+package httperrorfmt
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// OAuthEndpoint selects the kind of OAuth 2.0 endpoint an OAuthFormatter serves
+type OAuthEndpoint int
+
+const (
+	// OAuthResourceEndpoint is a protected resource using RFC 6750 Bearer tokens
+	OAuthResourceEndpoint OAuthEndpoint = iota
+	// OAuthTokenEndpoint is an RFC 6749 authorization server token endpoint
+	OAuthTokenEndpoint
+)
+
+// OAuthFormatter formats errors as RFC 6749 error responses and adds
+// RFC 6750 Bearer challenges to 401 responses. On token endpoints 401s
+// challenge for client authentication instead.
+type OAuthFormatter struct {
+	Realm    string
+	Scope    string
+	ErrorURI string
+	Endpoint OAuthEndpoint
+}
+
+// OAuthErrorResponse represents an RFC 6749 error response
+type OAuthErrorResponse struct {
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description,omitempty"`
+	ErrorURI         string `json:"error_uri,omitempty"`
+}
+
+// Format implements Formatter interface for OAuth 2.0 error responses.
+// Errors implementing OAuthError() string choose their own error code.
+func (f *OAuthFormatter) Format(w http.ResponseWriter, r *http.Request, err HTTPError) {
+	r, err = normalize(r, err)
+	response := OAuthErrorResponse{
+		Error:            oauthErrorCode(err, f.Endpoint),
+		ErrorDescription: oauthSanitize(publicMessage(err)),
+		ErrorURI:         f.ErrorURI,
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Pragma", "no-cache")
+	if err.StatusCode() == http.StatusUnauthorized {
+		if f.Endpoint == OAuthTokenEndpoint {
+			w.Header().Set("WWW-Authenticate", f.clientChallenge(r))
+		} else {
+			w.Header().Set("WWW-Authenticate", f.bearerChallenge(r, response))
+		}
+	}
+	writeHeader(w, r, err, "application/json;charset=UTF-8")
+
+	data, _ := json.Marshal(response)
+	w.Write(data)
+}
+
+// bearerChallenge builds the RFC 6750 WWW-Authenticate value for a 401
+func (f *OAuthFormatter) bearerChallenge(r *http.Request, response OAuthErrorResponse) string {
+	challenge := BearerChallenge(f.Realm).Param("scope", f.Scope)
+
+	// Requests without credentials get a bare challenge (RFC 6750 section 3.1)
+	if r.Header.Get("Authorization") != "" {
+		challenge = challenge.
+			Param("error", response.Error).
+			Param("error_description", response.ErrorDescription).
+			Param("error_uri", response.ErrorURI)
+	}
+	return challenge.String()
+}
+
+// clientChallenge builds the WWW-Authenticate value for a failed client
+// authentication at the token endpoint, using the scheme the client tried
+// (RFC 6749 section 5.2)
+func (f *OAuthFormatter) clientChallenge(r *http.Request) string {
+	scheme, _, _ := strings.Cut(r.Header.Get("Authorization"), " ")
+	if scheme == "" || strings.IndexFunc(scheme, func(c rune) bool { return !isTokenChar(c) }) >= 0 {
+		scheme = "Basic"
+	}
+	return Challenge{Scheme: scheme}.Param("realm", f.Realm).String()
+}
+
+// oauthErrorCode returns the RFC 6749 error code for an error
+func oauthErrorCode(err HTTPError, endpoint OAuthEndpoint) string {
+	var o interface{ OAuthError() string }
+	if errors.As(err, &o) && o.OAuthError() != "" {
+		return o.OAuthError()
+	}
+
+	if endpoint == OAuthTokenEndpoint {
+		switch status := err.StatusCode(); {
+		case status == http.StatusUnauthorized:
+			return "invalid_client"
+		case status == http.StatusForbidden:
+			return "unauthorized_client"
+		case status == http.StatusServiceUnavailable:
+			return "temporarily_unavailable"
+		case status >= 500:
+			return "server_error"
+		default:
+			return "invalid_request"
+		}
+	}
+
+	switch status := err.StatusCode(); {
+	case status == http.StatusUnauthorized:
+		return "invalid_token"
+	case status == http.StatusForbidden:
+		return "insufficient_scope"
+	case status == http.StatusServiceUnavailable:
+		return "temporarily_unavailable"
+	case status >= 500:
+		return "server_error"
+	default:
+		return "invalid_request"
+	}
+}
+
+// oauthSanitize drops characters RFC 6749 forbids in error_description
+func oauthSanitize(s string) string {
+	return strings.Map(func(c rune) rune {
+		if c < 0x20 || c > 0x7e || c == '"' || c == '\\' {
+			return -1
+		}
+		return c
+	}, s)
+}
+
+// quoteParam renders an auth-param value as a quoted-string
+func quoteParam(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
@@ -0,0 +1,69 @@
+package httperrorfmt
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+)
+
+// ODataFormatter formats errors as OData v4 JSON error responses
+type ODataFormatter struct {
+	PrettyPrint bool
+}
+
+// ODataErrorResponse is the envelope of an OData error
+type ODataErrorResponse struct {
+	Error ODataError `json:"error"`
+}
+
+// ODataError represents an OData error object
+type ODataError struct {
+	Code    string             `json:"code"`
+	Message string             `json:"message"`
+	Target  string             `json:"target,omitempty"`
+	Details []ODataErrorDetail `json:"details,omitempty"`
+}
+
+// ODataErrorDetail represents an entry in the OData details array
+type ODataErrorDetail struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Target  string `json:"target,omitempty"`
+}
+
+// Format implements Formatter interface for OData error responses. The code is
+// taken from ErrorCode() string, falling back to the status code, and causes
+// become details.
+func (f *ODataFormatter) Format(w http.ResponseWriter, r *http.Request, err HTTPError) {
+	r, err = normalize(r, err)
+	w.Header().Set("OData-Version", "4.0")
+	writeHeader(w, r, err, "application/json")
+
+	code := errorCode(err)
+	if code == "" {
+		code = strconv.Itoa(err.StatusCode())
+	}
+	response := ODataErrorResponse{
+		Error: ODataError{
+			Code:    code,
+			Message: publicMessage(err),
+			Target:  errorPath(err),
+		},
+	}
+	for _, cause := range causesOf(err) {
+		response.Error.Details = append(response.Error.Details, ODataErrorDetail{
+			Code:    cause.Reason,
+			Message: cause.Message,
+			Target:  cause.Field,
+		})
+	}
+
+	var data []byte
+	if f.PrettyPrint {
+		data, _ = json.MarshalIndent(response, "", "  ")
+	} else {
+		data, _ = json.Marshal(response)
+	}
+
+	w.Write(data)
+}
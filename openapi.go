@@ -0,0 +1,285 @@
+package httperrorfmt
+
+import (
+	"encoding/json"
+	"maps"
+	"mime"
+	"net/http"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// OpenAPIComponents holds OpenAPI 3.1 components describing error responses.
+// Merge it into the components object of an API description.
+type OpenAPIComponents struct {
+	Schemas   map[string]any `json:"schemas"`
+	Responses map[string]any `json:"responses"`
+}
+
+// DefaultOpenAPIStatuses are the statuses OpenAPI describes when given none
+var DefaultOpenAPIStatuses = []int{400, 401, 403, 404, 405, 409, 422, 429, 500, 503}
+
+// OpenAPI describes the error responses f renders as OpenAPI 3.1 components,
+// one response per status named like "Error404", with every media type f
+// negotiates. JSON and problem details bodies reference the ErrorResponse and
+// ProblemDetails schemas, which are derived from the response types so they
+// can't drift. Bodies of other JSON formatters get a schema inferred from a
+// rendered sample, and all other bodies are described as strings. Each media
+// type carries a rendered example. Validation statuses (400 and 422) are
+// sampled with causes. Samples are rendered without hooks, logging, error
+// stores or post-processors, so describing f has no side effects.
+func OpenAPI(f Formatter, statuses ...int) *OpenAPIComponents {
+	if len(statuses) == 0 {
+		statuses = DefaultOpenAPIStatuses
+	}
+	c := &OpenAPIComponents{Schemas: make(map[string]any), Responses: make(map[string]any)}
+	errorResponse := jsonSchemaOf(reflect.TypeFor[ErrorResponse](), c.Schemas)
+	c.Schemas["ProblemDetails"] = problemSchema(c.Schemas["ErrorResponse"].(map[string]any))
+
+	formatters, sampler := openAPIFormatters(f), quietFormatter(f)
+	for _, status := range statuses {
+		content := make(map[string]any)
+		for _, accept := range slices.Sorted(maps.Keys(formatters)) {
+			r, _ := http.NewRequest(http.MethodGet, "/", nil)
+			if accept != "" {
+				r.Header.Set("Accept", accept)
+			}
+			resp := Render(sampler, r, openAPISample(status))
+			mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+			if mediaType == "" || content[mediaType] != nil {
+				continue
+			}
+
+			var schema, example any
+			json.Unmarshal(resp.Body, &example)
+			switch formatter := unwrapFormatter(formatters[accept]); {
+			case isPlainJSONFormatter(formatter) && example != nil:
+				schema = errorResponse
+			case isProblemFormatter(formatter) && example != nil:
+				schema = map[string]any{"$ref": "#/components/schemas/ProblemDetails"}
+			case example != nil:
+				schema = inferSchema(example)
+			default:
+				schema, example = map[string]any{"type": "string"}, string(resp.Body)
+			}
+			content[mediaType] = map[string]any{"schema": schema, "example": example}
+		}
+
+		response := map[string]any{"description": statusText(status), "content": content}
+		if headers := openAPIHeaders(status); len(headers) > 0 {
+			response["headers"] = headers
+		}
+		c.Responses["Error"+strconv.Itoa(status)] = response
+	}
+	return c
+}
+
+// openAPIFormatters returns the formatters of f by the Accept value that
+// selects them. Formatters without negotiation are keyed by "".
+func openAPIFormatters(f Formatter) map[string]Formatter {
+	cn, ok := f.(*ContentNegotiator)
+	if cnf, isCNF := f.(*ContentNegotiatingFormatter); isCNF {
+		cn, ok = cnf.ContentNegotiator, true
+	}
+	if !ok || len(cn.formatters) == 0 {
+		return map[string]Formatter{"": f}
+	}
+	return maps.Clone(cn.formatters)
+}
+
+// quietFormatter returns a copy of f that renders the same bodies without
+// running hooks, logging, error stores or post-processors
+func quietFormatter(f Formatter) Formatter {
+	cn, ok := f.(*ContentNegotiator)
+	if cnf, isCNF := f.(*ContentNegotiatingFormatter); isCNF {
+		cn, ok = cnf.ContentNegotiator, true
+	}
+	if !ok {
+		return unwrapFormatter(f)
+	}
+	quiet := *cn
+	quiet.store, quiet.hooks, quiet.postProcessors = nil, nil, nil
+	quiet.defaults = unwrapFormatter(cn.defaults)
+	quiet.formatters = unwrapFormatters(cn.formatters)
+	quiet.prefixes = unwrapFormatters(cn.prefixes)
+	quiet.statuses = unwrapFormatters(cn.statuses)
+	return &quiet
+}
+
+// unwrapFormatters applies unwrapFormatter to every formatter of m
+func unwrapFormatters[K comparable](m map[K]Formatter) map[K]Formatter {
+	unwrapped := make(map[K]Formatter, len(m))
+	for key, f := range m {
+		unwrapped[key] = unwrapFormatter(f)
+	}
+	return unwrapped
+}
+
+// unwrapFormatter returns the formatter that renders the body for wrappers
+// that only add headers or side effects
+func unwrapFormatter(f Formatter) Formatter {
+	for {
+		switch w := f.(type) {
+		case *DeprecatedFormatter:
+			f = w.Formatter
+		case *HookedFormatter:
+			f = w.Formatter
+		case *LoggingFormatter:
+			f = w.Formatter
+		case *SecureFormatter:
+			f = w.Formatter
+		default:
+			return f
+		}
+	}
+}
+
+// isPlainJSONFormatter reports whether f renders ErrorResponse bodies as is
+func isPlainJSONFormatter(f Formatter) bool {
+	j, ok := f.(*JSONFormatter)
+	return ok && j.Shape == nil
+}
+
+// isProblemFormatter reports whether f renders problem details
+func isProblemFormatter(f Formatter) bool {
+	_, ok := f.(*ProblemFormatter)
+	return ok
+}
+
+// openAPISample returns the error rendered for a status
+func openAPISample(status int) HTTPError {
+	e := New(status, statusText(status))
+	if status == http.StatusBadRequest || status == http.StatusUnprocessableEntity {
+		return &sampleValidationError{HTTPError: e}
+	}
+	return e
+}
+
+// sampleValidationError is a sample error with a field cause
+type sampleValidationError struct {
+	HTTPError
+}
+
+// Causes returns a sample field cause
+func (e *sampleValidationError) Causes() []Cause {
+	return []Cause{{Reason: "required", Message: "Name is required", Field: "name"}}
+}
+
+// openAPIHeaders describes the headers sent with errors of a status
+func openAPIHeaders(status int) map[string]any {
+	header := func(description string) map[string]any {
+		return map[string]any{"description": description, "schema": map[string]any{"type": "string"}}
+	}
+	headers := make(map[string]any)
+	switch status {
+	case http.StatusUnauthorized:
+		headers["WWW-Authenticate"] = header("Authentication challenges")
+	case http.StatusMethodNotAllowed:
+		headers["Allow"] = header("Methods the resource supports")
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+		headers["Retry-After"] = header("Seconds to wait before retrying")
+	}
+	return headers
+}
+
+// problemSchema describes problem details: the RFC 9457 members plus the
+// extensions the formatter adds, which share the ErrorResponse schemas
+func problemSchema(errorResponse map[string]any) map[string]any {
+	properties := map[string]any{
+		"type":     map[string]any{"type": "string", "format": "uri-reference"},
+		"title":    map[string]any{"type": "string"},
+		"status":   map[string]any{"type": "integer"},
+		"detail":   map[string]any{"type": "string"},
+		"instance": map[string]any{"type": "string", "format": "uri-reference"},
+	}
+	shared := errorResponse["properties"].(map[string]any)
+	for _, name := range []string{"error_code", "error_id", "timestamp", "method", "path", "request_id", "retry_after",
+		"retryable", "idempotency_safe", "rate_limit", "allowed_methods", "blocked_by", "help_url", "remediation", "causes", "category", "items", "stack"} {
+		properties[name] = shared[name]
+	}
+	return map[string]any{"type": "object", "properties": properties, "additionalProperties": true}
+}
+
+// jsonSchemaOf derives a JSON Schema from a Go type by its json tags. Named
+// structs are added to schemas and referenced.
+func jsonSchemaOf(t reflect.Type, schemas map[string]any) map[string]any {
+	switch t.Kind() {
+	case reflect.Pointer:
+		return jsonSchemaOf(t.Elem(), schemas)
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": jsonSchemaOf(t.Elem(), schemas)}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": jsonSchemaOf(t.Elem(), schemas)}
+	case reflect.Struct:
+	default:
+		return map[string]any{}
+	}
+
+	ref := map[string]any{"$ref": "#/components/schemas/" + t.Name()}
+	if _, done := schemas[t.Name()]; done {
+		return ref
+	}
+	properties := make(map[string]any)
+	schema := map[string]any{"type": "object", "properties": properties}
+	schemas[t.Name()] = schema
+	var required []string
+	for _, field := range reflect.VisibleFields(t) {
+		if !field.IsExported() || field.Anonymous {
+			continue
+		}
+		name, options, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		properties[name] = jsonSchemaOf(field.Type, schemas)
+		if !strings.Contains(options, "omitempty") {
+			required = append(required, name)
+		}
+	}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return ref
+}
+
+// inferSchema derives a JSON Schema from a decoded JSON sample
+func inferSchema(v any) map[string]any {
+	switch v := v.(type) {
+	case map[string]any:
+		properties := make(map[string]any, len(v))
+		for key, value := range v {
+			properties[key] = inferSchema(value)
+		}
+		return map[string]any{"type": "object", "properties": properties}
+	case []any:
+		if len(v) == 0 {
+			return map[string]any{"type": "array"}
+		}
+		return map[string]any{"type": "array", "items": inferSchema(v[0])}
+	case string:
+		return map[string]any{"type": "string"}
+	case float64:
+		if v == float64(int64(v)) {
+			return map[string]any{"type": "integer"}
+		}
+		return map[string]any{"type": "number"}
+	case bool:
+		return map[string]any{"type": "boolean"}
+	default:
+		return map[string]any{}
+	}
+}
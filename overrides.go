@@ -0,0 +1,184 @@
+package httperrorfmt
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Override changes the public face of errors on matching routes. Overrides
+// are usually loaded from a file with Router.LoadOverrides, so the wording of
+// error pages can change without code changes.
+type Override struct {
+	// Pattern selects requests with Router.Handle syntax, e.g. "GET /checkout/"
+	Pattern string `json:"pattern"`
+	// Statuses limits the override to these statuses. A status class such as 5
+	// covers 500-599. Empty matches every status.
+	Statuses []int `json:"statuses,omitempty"`
+	// Message replaces the public message, including its translations
+	Message string `json:"message,omitempty"`
+	// DocURL replaces the documentation URL, shown when DocURLs is enabled
+	DocURL string `json:"doc_url,omitempty"`
+	// Format forces a media type, e.g. "application/json", regardless of Accept
+	Format string `json:"format,omitempty"`
+	// Template is an html/template file for clients accepting HTML, relative
+	// to the overrides file
+	Template string `json:"template,omitempty"`
+}
+
+// overridesFile is the layout of an overrides file
+type overridesFile struct {
+	Overrides []Override `json:"overrides"`
+}
+
+// override is a parsed Override
+type override struct {
+	route
+	Override
+	template *template.Template
+}
+
+// LoadOverrides reads a JSON overrides file, replacing the overrides loaded
+// before. Call it again, e.g. on SIGHUP, to pick up edits; on failure the
+// previous overrides stay in place.
+//
+//	{"overrides": [
+//	  {"pattern": "/checkout/", "statuses": [402], "message": "Your card was declined", "template": "declined.html"}
+//	]}
+func (rt *Router) LoadOverrides(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var file overridesFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return fmt.Errorf("httperrorfmt: %s: %w", path, err)
+	}
+	overrides := make([]override, 0, len(file.Overrides))
+	for _, o := range file.Overrides {
+		if o.Pattern == "" {
+			return fmt.Errorf("httperrorfmt: %s: override without pattern", path)
+		}
+		parsed := override{route: parseRoute(o.Pattern, nil), Override: o}
+		if o.Template != "" {
+			name := o.Template
+			if !filepath.IsAbs(name) {
+				name = filepath.Join(filepath.Dir(path), name)
+			}
+			if parsed.template, err = template.ParseFiles(name); err != nil {
+				return fmt.Errorf("httperrorfmt: %s: %w", path, err)
+			}
+		}
+		overrides = append(overrides, parsed)
+	}
+	rt.overrides.Store(&overrides)
+	return nil
+}
+
+// matchOverride returns the most specific override for a request and error
+func (rt *Router) matchOverride(r *http.Request, err HTTPError) *override {
+	if rt == nil {
+		return nil
+	}
+	overrides := rt.overrides.Load()
+	if overrides == nil {
+		return nil
+	}
+	var path []string
+	if r.URL != nil {
+		path = splitPath(r.URL.Path)
+	}
+	var best *override
+	bestScore := -1
+	for i := range *overrides {
+		o := &(*overrides)[i]
+		statusScore := o.matchStatus(err.StatusCode())
+		if statusScore < 0 || (o.pattern != r.Pattern && (r.URL == nil || !o.matches(r.Method, path))) {
+			continue
+		}
+		if score := o.specificity()*3 + statusScore; score > bestScore {
+			best, bestScore = o, score
+		}
+	}
+	return best
+}
+
+// matchStatus ranks how an override matches a status: 2 for the exact status,
+// 1 for its class, 0 for overrides without statuses and -1 for no match
+func (o *override) matchStatus(status int) int {
+	switch {
+	case len(o.Statuses) == 0:
+		return 0
+	case slices.Contains(o.Statuses, status):
+		return 2
+	case slices.Contains(o.Statuses, status/100):
+		return 1
+	default:
+		return -1
+	}
+}
+
+// apply returns the request and error to format with the override
+func (o *override) apply(r *http.Request, err HTTPError) (*http.Request, HTTPError) {
+	if o.Message != "" {
+		// Drop the translation normalize picked; the override replaces it
+		if l, ok := err.(*localizedError); ok {
+			err = l.HTTPError
+		}
+		err = &messageOverride{HTTPError: err, message: o.Message}
+	}
+	if o.DocURL != "" {
+		err = &docURLOverride{HTTPError: err, docURL: o.DocURL}
+	}
+	if o.Format != "" {
+		r = r.Clone(r.Context())
+		r.Header.Set("Accept", o.Format)
+	}
+	return r, err
+}
+
+// formatter returns the formatter the override imposes, if any
+func (o *override) formatter(r *http.Request) Formatter {
+	if o.template != nil && strings.Contains(r.Header.Get("Accept"), "text/html") {
+		return &HTMLFormatter{Template: o.template}
+	}
+	return nil
+}
+
+// messageOverride replaces the public message of an error
+type messageOverride struct {
+	HTTPError
+	message string
+}
+
+// Message returns the overriding message
+func (e *messageOverride) Message() string { return e.message }
+
+// PublicMessage returns the overriding message
+func (e *messageOverride) PublicMessage() string { return e.message }
+
+// InternalMessage returns the internal message of the original error
+func (e *messageOverride) InternalMessage() string { return internalMessage(e.HTTPError) }
+
+// Translations returns nil, so the overriding message isn't translated away
+func (e *messageOverride) Translations() map[string]string { return nil }
+
+// Unwrap returns the original error
+func (e *messageOverride) Unwrap() error { return e.HTTPError }
+
+// docURLOverride replaces the documentation URL of an error
+type docURLOverride struct {
+	HTTPError
+	docURL string
+}
+
+// DocURL returns the overriding documentation URL
+func (e *docURLOverride) DocURL() string { return e.docURL }
+
+// Unwrap returns the original error
+func (e *docURLOverride) Unwrap() error { return e.HTTPError }
@@ -0,0 +1,30 @@
+package httperrorfmt
+
+import "net/http"
+
+// Response is a fully rendered error response
+type Response struct {
+	Status int
+	Header http.Header
+	Body   []byte
+}
+
+// PostProcessor rewrites rendered error responses before they are written,
+// e.g. to inject a CSRF token, sign the body or rewrite URLs
+type PostProcessor interface {
+	PostProcess(r *http.Request, err HTTPError, resp *Response)
+}
+
+// PostProcessorFunc adapts an ordinary function to a PostProcessor
+type PostProcessorFunc func(r *http.Request, err HTTPError, resp *Response)
+
+// PostProcess calls f(r, err, resp)
+func (f PostProcessorFunc) PostProcess(r *http.Request, err HTTPError, resp *Response) {
+	f(r, err, resp)
+}
+
+// Use adds post-processors that run, in order, on every response the negotiator renders
+func (cn *ContentNegotiator) Use(processors ...PostProcessor) *ContentNegotiator {
+	cn.postProcessors = append(cn.postProcessors, processors...)
+	return cn
+}
@@ -0,0 +1,57 @@
+// Package presets provides ready-made content negotiators for common API styles
+package presets
+
+import (
+	"github.com/perbu/httperrorfmt"
+)
+
+// REST returns a negotiator for JSON APIs with XML, HTML and plain text alternatives.
+// JSON is used when the client expresses no preference.
+func REST() *httperrorfmt.ContentNegotiator {
+	jsonFormatter := &httperrorfmt.JSONFormatter{}
+	return httperrorfmt.NewContentNegotiator().
+		Register("application/json", jsonFormatter).
+		Register("application/xml", &httperrorfmt.XMLFormatter{}).
+		Register("text/html", httperrorfmt.NewHTMLFormatter()).
+		Register("text/plain", &httperrorfmt.TextFormatter{}).
+		Alias("text/json", "application/json").
+		Alias("text/xml", "application/xml").
+		SetDefault(jsonFormatter)
+}
+
+// Hypermedia returns a negotiator rendering vnd.error documents, also for clients
+// asking for plain JSON, HAL documents for HAL clients and an HTML page for browsers
+func Hypermedia() *httperrorfmt.ContentNegotiator {
+	vnd := &httperrorfmt.VndErrorFormatter{}
+	return httperrorfmt.NewContentNegotiator().
+		Register("application/vnd.error+json", vnd).
+		Register("application/hal+json", &httperrorfmt.HALFormatter{}).
+		Register("application/json", vnd).
+		Register("text/html", httperrorfmt.NewHTMLFormatter()).
+		Alias("application/vnd.error", "application/vnd.error+json").
+		SetDefault(vnd)
+}
+
+// Enterprise returns a negotiator rendering OData errors for JSON clients and
+// SOAP 1.2 faults for SOAP and XML clients
+func Enterprise() *httperrorfmt.ContentNegotiator {
+	odata := &httperrorfmt.ODataFormatter{}
+	soap := &httperrorfmt.SOAPFormatter{}
+	return httperrorfmt.NewContentNegotiator().
+		Register("application/json", odata).
+		Register("application/soap+xml", soap).
+		Register("application/xml", soap).
+		Alias("text/xml", "application/xml").
+		SetDefault(odata)
+}
+
+// Cloud returns a negotiator rendering the Google API error model for JSON clients
+// and the AWS JSON protocol shape for clients asking for application/x-amz-json
+func Cloud() *httperrorfmt.ContentNegotiator {
+	google := &httperrorfmt.GoogleErrorFormatter{}
+	return httperrorfmt.NewContentNegotiator().
+		Register("application/json", google).
+		Register("application/x-amz-json-1.1", &httperrorfmt.AWSErrorFormatter{}).
+		Register("application/x-amz-json-1.0", &httperrorfmt.AWSErrorFormatter{ContentType: "application/x-amz-json-1.0"}).
+		SetDefault(google)
+}
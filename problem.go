@@ -0,0 +1,159 @@
+package httperrorfmt
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+)
+
+// ProblemFormatter formats errors as RFC 9457 problem details
+type ProblemFormatter struct {
+	PrettyPrint bool
+	// IncludeInstance sets "instance" to the requested path
+	IncludeInstance bool
+}
+
+// ProblemDetails represents an RFC 9457 problem details object
+type ProblemDetails struct {
+	Type     string `json:"type,omitempty"`
+	Title    string `json:"title,omitempty"`
+	Status   int    `json:"status,omitempty"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+	// Extensions are serialized as additional top-level members
+	Extensions map[string]any `json:"-"`
+}
+
+// problemMembers are the members defined by RFC 9457 itself
+var problemMembers = []string{"type", "title", "status", "detail", "instance"}
+
+// MarshalJSON renders the standard members followed by the extensions in key order
+func (p ProblemDetails) MarshalJSON() ([]byte, error) {
+	type plain ProblemDetails
+	data, err := json.Marshal(plain(p))
+	if err != nil || len(p.Extensions) == 0 {
+		return data, err
+	}
+
+	keys := make([]string, 0, len(p.Extensions))
+	for key := range p.Extensions {
+		if !slices.Contains(problemMembers, key) {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+
+	var buf bytes.Buffer
+	buf.Write(data[:len(data)-1])
+	for i, key := range keys {
+		value, err := json.Marshal(p.Extensions[key])
+		if err != nil {
+			return nil, err
+		}
+		if i > 0 || len(data) > 2 {
+			buf.WriteByte(',')
+		}
+		name, _ := json.Marshal(key)
+		buf.Write(name)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// Format implements Formatter interface for problem details responses. The type
+// comes from ProblemType() string and extensions from Extensions() map[string]any
+// when the error implements them. Batch items are added as the "items" extension.
+func (f *ProblemFormatter) Format(w http.ResponseWriter, r *http.Request, err HTTPError) {
+	r, err = normalize(r, err)
+	writeHeader(w, r, err, "application/problem+json")
+
+	problem := ProblemDetails{
+		Type:       "about:blank",
+		Title:      settingsFrom(r).features.Registry.title(err),
+		Status:     err.StatusCode(),
+		Detail:     publicMessage(err),
+		Extensions: make(map[string]any),
+	}
+	var t interface{ ProblemType() string }
+	if errors.As(err, &t) && t.ProblemType() != "" {
+		problem.Type = t.ProblemType()
+	}
+	if f.IncludeInstance && r.URL != nil {
+		problem.Instance = r.URL.RequestURI()
+	}
+
+	var e interface{ Extensions() map[string]any }
+	if errors.As(err, &e) {
+		for key, value := range e.Extensions() {
+			problem.Extensions[key] = value
+		}
+	}
+	if code := errorCode(err); code != "" {
+		problem.Extensions["error_code"] = code
+	}
+	if blockedBy := blockedByOf(err); blockedBy != "" {
+		problem.Extensions["blocked_by"] = blockedBy
+	}
+	if allowed := allowedMethodsOf(err); len(allowed) > 0 {
+		problem.Extensions["allowed_methods"] = allowed
+	}
+	if info := rateLimitOf(err); info != nil {
+		problem.Extensions["rate_limit"] = info
+	}
+	hints := retryHintsOf(err)
+	if hints.Retryable != nil {
+		problem.Extensions["retryable"] = *hints.Retryable
+	}
+	if hints.IdempotencySafe != nil {
+		problem.Extensions["idempotency_safe"] = *hints.IdempotencySafe
+	}
+	if items, ok := batchItemsOf(err); ok {
+		problem.Extensions["items"] = items
+	}
+
+	d := collectDetails(r, err, settingsFrom(r).features)
+	if d.Category != "" {
+		problem.Extensions["category"] = d.Category
+	}
+	if d.ErrorID != "" {
+		problem.Extensions["error_id"] = d.ErrorID
+	}
+	if d.Timestamp != "" {
+		problem.Extensions["timestamp"] = d.Timestamp
+	}
+	if d.RetryAfter > 0 {
+		problem.Extensions["retry_after"] = d.RetryAfter
+	}
+	if d.RequestID != "" {
+		problem.Extensions["request_id"] = d.RequestID
+	}
+	if d.Path != "" {
+		problem.Extensions["method"] = d.Method
+		problem.Extensions["path"] = d.Path
+	}
+	if d.DocURL != "" {
+		problem.Extensions["help_url"] = d.DocURL
+	}
+	if remediation := remediationOf(err); remediation != nil {
+		problem.Extensions["remediation"] = remediation
+	}
+	if len(d.Causes) > 0 {
+		problem.Extensions["causes"] = d.Causes
+	}
+	if d.Stack != "" {
+		problem.Extensions["stack"] = d.Stack
+	}
+
+	var data []byte
+	if f.PrettyPrint {
+		data, _ = json.MarshalIndent(problem, "", "  ")
+	} else {
+		data, _ = json.Marshal(problem)
+	}
+
+	w.Write(data)
+}
@@ -0,0 +1,24 @@
+package httperrorfmt
+
+import "net/http"
+
+// NewProxyErrorHandler returns a handler for httputil.ReverseProxy.ErrorHandler
+// that renders proxy failures through formatter. Errors are classified with
+// NetworkError: timeouts become 504 Gateway Timeout, refused connections 503
+// Service Unavailable, and failures to reach the backend and any other error
+// 502 Bad Gateway. The proxy error is kept as the internal message. A nil
+// formatter means Default.
+func NewProxyErrorHandler(formatter Formatter) func(http.ResponseWriter, *http.Request, error) {
+	return func(w http.ResponseWriter, r *http.Request, err error) {
+		OrDefault(formatter).Format(w, r, proxyError(err))
+	}
+}
+
+// proxyError classifies an error of a reverse proxy. A truncated response is
+// the backend's fault here, not the client's.
+func proxyError(err error) *Error {
+	if e := networkError(err); e != nil && e.status != http.StatusBadRequest {
+		return e
+	}
+	return Wrap(err, http.StatusBadGateway, "The upstream service failed")
+}
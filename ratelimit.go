@@ -0,0 +1,58 @@
+package httperrorfmt
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RateLimitInfo describes the rate limit a request ran into
+type RateLimitInfo struct {
+	Limit     int `json:"limit" xml:"limit"`
+	Remaining int `json:"remaining" xml:"remaining"`
+	// Reset is how long until the quota resets
+	Reset time.Duration `json:"-" xml:"-"`
+	// ResetSeconds is Reset in whole seconds, filled in for bodies
+	ResetSeconds int64 `json:"reset" xml:"reset"`
+	// Policy is a RateLimit-Policy value such as `100;w=60`
+	Policy string `json:"policy,omitempty" xml:"policy,omitempty"`
+}
+
+// WithRateLimit attaches rate limit information, sent as RateLimit-* and
+// X-RateLimit-* headers and in JSON bodies
+func (e *Error) WithRateLimit(info RateLimitInfo) *Error {
+	e.rateLimit = &info
+	return e
+}
+
+// RateLimit returns the attached rate limit information, or nil
+func (e *Error) RateLimit() *RateLimitInfo { return e.rateLimit }
+
+// rateLimitOf returns the rate limit information of errors implementing
+// RateLimit() *RateLimitInfo, with ResetSeconds filled in
+func rateLimitOf(err HTTPError) *RateLimitInfo {
+	var l interface{ RateLimit() *RateLimitInfo }
+	if !errors.As(err, &l) || l.RateLimit() == nil {
+		return nil
+	}
+	info := *l.RateLimit()
+	info.ResetSeconds = int64((max(info.Reset, 0) + time.Second - 1) / time.Second)
+	return &info
+}
+
+// setRateLimitHeaders sends the rate limit information of an error in both the
+// IETF RateLimit fields, with Reset as delay-seconds, and the common
+// X-RateLimit fields, with Reset as a Unix timestamp
+func setRateLimitHeaders(header http.Header, info *RateLimitInfo) {
+	limit, remaining := strconv.Itoa(info.Limit), strconv.Itoa(max(info.Remaining, 0))
+	header.Set("RateLimit-Limit", limit)
+	header.Set("RateLimit-Remaining", remaining)
+	header.Set("RateLimit-Reset", strconv.FormatInt(info.ResetSeconds, 10))
+	if info.Policy != "" {
+		header.Set("RateLimit-Policy", info.Policy)
+	}
+	header.Set("X-RateLimit-Limit", limit)
+	header.Set("X-RateLimit-Remaining", remaining)
+	header.Set("X-RateLimit-Reset", strconv.FormatInt(time.Now().Add(info.Reset).Unix(), 10))
+}
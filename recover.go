@@ -0,0 +1,158 @@
+package httperrorfmt
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"runtime"
+	"runtime/debug"
+	"strings"
+)
+
+// PanicKind classifies a recovered panic value
+type PanicKind string
+
+// Panic classifications
+const (
+	PanicKindError           PanicKind = "error"
+	PanicKindString          PanicKind = "string"
+	PanicKindNilMapWrite     PanicKind = "nil_map_write"
+	PanicKindIndexOutOfRange PanicKind = "index_out_of_range"
+	PanicKindNilPointer      PanicKind = "nil_pointer_dereference"
+	PanicKindTypeAssertion   PanicKind = "type_assertion"
+	PanicKindDivideByZero    PanicKind = "divide_by_zero"
+	PanicKindRuntime         PanicKind = "runtime"
+	PanicKindOther           PanicKind = "other"
+)
+
+// ClassifyPanic returns the classification of a value passed to panic
+func ClassifyPanic(v any) PanicKind {
+	var typeErr *runtime.TypeAssertionError
+	if err, ok := v.(error); ok && errors.As(err, &typeErr) {
+		return PanicKindTypeAssertion
+	}
+
+	switch v := v.(type) {
+	case runtime.Error:
+		msg := v.Error()
+		switch {
+		case strings.Contains(msg, "assignment to entry in nil map"):
+			return PanicKindNilMapWrite
+		case strings.Contains(msg, "index out of range"), strings.Contains(msg, "slice bounds out of range"):
+			return PanicKindIndexOutOfRange
+		case strings.Contains(msg, "nil pointer dereference"):
+			return PanicKindNilPointer
+		case strings.Contains(msg, "divide by zero"):
+			return PanicKindDivideByZero
+		default:
+			return PanicKindRuntime
+		}
+	case error:
+		return PanicKindError
+	case string:
+		return PanicKindString
+	default:
+		return PanicKindOther
+	}
+}
+
+// PanicError is the error produced for a recovered panic. Clients only see a
+// generic 500; the value, classification and stack are kept for debugging.
+type PanicError struct {
+	*errorBase
+	Value any
+	Kind  PanicKind
+	stack []byte
+}
+
+// NewPanicError creates a 500 error from a recovered panic value and its stack
+func NewPanicError(v any, stack []byte) *PanicError {
+	e := &PanicError{
+		errorBase: New(http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError)).
+			WithInternal(fmt.Sprintf("panic: %v", v)),
+		Value: v,
+		Kind:  ClassifyPanic(v),
+		stack: stack,
+	}
+	if err, ok := v.(error); ok {
+		e.errorBase.err = err
+	}
+	return e
+}
+
+// PanicKind returns the classification of the panic value
+func (e *PanicError) PanicKind() PanicKind { return e.Kind }
+
+// Stack returns the stack trace captured when the panic was recovered
+func (e *PanicError) Stack() string { return string(e.stack) }
+
+// Recover returns middleware that turns panics into 500 responses rendered by f.
+// http.ErrAbortHandler is re-panicked so net/http can abort the response. A
+// handler that panics after writing its status or part of its body can't get
+// a clean error response, so its response is aborted the same way.
+func Recover(f Formatter) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rw := &recoverWriter{ResponseWriter: w}
+			defer func() {
+				v := recover()
+				if v == nil {
+					return
+				}
+				if v == http.ErrAbortHandler || rw.wrote {
+					panic(http.ErrAbortHandler)
+				}
+				OrDefault(f).Format(w, r, NewPanicError(v, debug.Stack()))
+			}()
+			next.ServeHTTP(rw, r)
+		})
+	}
+}
+
+// recoverWriter records whether the handler started its response
+type recoverWriter struct {
+	http.ResponseWriter
+	wrote bool
+}
+
+func (w *recoverWriter) WriteHeader(status int) {
+	// Informational responses leave the final status to be written
+	if status >= 200 {
+		w.wrote = true
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *recoverWriter) Write(p []byte) (int, error) {
+	w.wrote = true
+	return w.ResponseWriter.Write(p)
+}
+
+// Flush forwards to the underlying writer
+func (w *recoverWriter) Flush() {
+	w.wrote = true
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (w *recoverWriter) Unwrap() http.ResponseWriter { return w.ResponseWriter }
+
+// stackOf returns the stack trace attached to an error, if any
+func stackOf(err HTTPError) string {
+	var s interface{ Stack() string }
+	if errors.As(err, &s) {
+		return s.Stack()
+	}
+	return ""
+}
+
+// panicKindOf returns the panic classification of an error, if any
+func panicKindOf(err HTTPError) PanicKind {
+	var p interface{ PanicKind() PanicKind }
+	if errors.As(err, &p) {
+		return p.PanicKind()
+	}
+	return ""
+}
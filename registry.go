@@ -0,0 +1,136 @@
+package httperrorfmt
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+// CodeDefinition declares an application error code
+type CodeDefinition struct {
+	Code string
+	// Status is the HTTP status errors with the code get
+	Status int
+	// Message is the default public message
+	Message string
+	// Title is a short summary used as the problem details title. Empty means
+	// the status text.
+	Title string
+	// Description explains the code in the catalog. It is never sent to
+	// clients with errors.
+	Description string
+	// DocURL documents the code
+	DocURL string
+	// Internal codes identify errors in logs, hooks and stores but are never
+	// sent to clients
+	Internal bool
+}
+
+// Registry declares application error codes once, so every error with a
+// code gets the same status, message and documentation. Set it as
+// Features.Registry for formatters to take titles and documentation URLs from it.
+type Registry struct {
+	mu    sync.RWMutex
+	codes map[string]CodeDefinition
+	order []string
+}
+
+// NewRegistry creates a registry with the given definitions
+func NewRegistry(definitions ...CodeDefinition) *Registry {
+	reg := &Registry{codes: make(map[string]CodeDefinition)}
+	reg.Register(definitions...)
+	return reg
+}
+
+// Register adds definitions. It panics on an empty or duplicate code or an
+// invalid status, like http.ServeMux does on conflicting patterns.
+func (reg *Registry) Register(definitions ...CodeDefinition) *Registry {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	for _, def := range definitions {
+		if def.Code == "" {
+			panic("httperrorfmt: error code definition without code")
+		}
+		if _, exists := reg.codes[def.Code]; exists {
+			panic(fmt.Sprintf("httperrorfmt: error code %q registered twice", def.Code))
+		}
+		if def.Status < 400 || def.Status > 599 {
+			panic(fmt.Sprintf("httperrorfmt: error code %q has invalid status %d", def.Code, def.Status))
+		}
+		reg.codes[def.Code] = def
+		reg.order = append(reg.order, def.Code)
+	}
+	return reg
+}
+
+// Lookup returns the definition of a code
+func (reg *Registry) Lookup(code string) (CodeDefinition, bool) {
+	if reg == nil {
+		return CodeDefinition{}, false
+	}
+	reg.mu.RLock()
+	defer reg.mu.RUnlock()
+	def, ok := reg.codes[code]
+	return def, ok
+}
+
+// Definitions returns all definitions in registration order
+func (reg *Registry) Definitions() []CodeDefinition {
+	if reg == nil {
+		return nil
+	}
+	reg.mu.RLock()
+	defer reg.mu.RUnlock()
+	definitions := make([]CodeDefinition, len(reg.order))
+	for i, code := range reg.order {
+		definitions[i] = reg.codes[code]
+	}
+	return definitions
+}
+
+// New creates an error for a registered code. Unregistered codes give a 500
+// whose internal message names the missing code.
+func (reg *Registry) New(code string) *Error {
+	def, ok := reg.Lookup(code)
+	if !ok {
+		return New(http.StatusInternalServerError, "Internal server error").
+			WithInternal(fmt.Sprintf("httperrorfmt: unregistered error code %q", code))
+	}
+	e := New(def.Status, def.Message).WithCode(def.Code)
+	e.codeInternal = def.Internal
+	return e
+}
+
+// Wrap creates an error for a registered code around err, like Wrap
+func (reg *Registry) Wrap(err error, code string) *Error {
+	e := reg.New(code)
+	e.err = err
+	return e
+}
+
+// title returns the registered title of an error's code, or the status text
+func (reg *Registry) title(err HTTPError) string {
+	if def, ok := reg.Lookup(internalErrorCode(err)); ok && !def.Internal && def.Title != "" {
+		return def.Title
+	}
+	return statusText(err.StatusCode())
+}
+
+// docURL returns the registered documentation URL of an error's code
+func (reg *Registry) docURL(err HTTPError) string {
+	if def, ok := reg.Lookup(internalErrorCode(err)); ok && !def.Internal {
+		return def.DocURL
+	}
+	return ""
+}
+
+// internalErrorCode returns the error code for logs and hooks, including
+// codes that are never sent to clients
+func internalErrorCode(err HTTPError) string {
+	var c interface{ InternalErrorCode() string }
+	if errors.As(err, &c) && c.InternalErrorCode() != "" {
+		return c.InternalErrorCode()
+	}
+	return errorCode(err)
+}
@@ -0,0 +1,63 @@
+package httperrorfmt
+
+import "errors"
+
+// RemediationAction is what a client can do to recover from an error
+type RemediationAction string
+
+// Remediation actions clients commonly automate. Applications may define their own.
+const (
+	// RefreshToken asks the client to refresh its access token and retry
+	RefreshToken RemediationAction = "REFRESH_TOKEN"
+	// Reauthenticate asks the client to sign the user in again
+	Reauthenticate RemediationAction = "REAUTHENTICATE"
+	// UpgradePlan asks the user to upgrade their plan, e.g. to lift a quota
+	UpgradePlan RemediationAction = "UPGRADE_PLAN"
+	// VerifyAccount asks the user to verify their email or identity
+	VerifyAccount RemediationAction = "VERIFY_ACCOUNT"
+	// AcceptTerms asks the user to accept updated terms
+	AcceptTerms RemediationAction = "ACCEPT_TERMS"
+	// FixInput asks the user to correct the fields named in the causes
+	FixInput RemediationAction = "FIX_INPUT"
+	// RetryLater asks the client to retry after Retry-After
+	RetryLater RemediationAction = "RETRY_LATER"
+	// ContactSupport asks the user to contact support
+	ContactSupport RemediationAction = "CONTACT_SUPPORT"
+)
+
+// Remediation tells clients how to recover from an error, so they can drive
+// the user through it instead of showing the message
+type Remediation struct {
+	Action RemediationAction `json:"action"`
+	// Params holds what the action needs, e.g. the plan to upgrade to
+	Params map[string]string `json:"params,omitempty"`
+	// Link is where the user completes the action, e.g. a billing page
+	Link string `json:"link,omitempty"`
+}
+
+// WithRemediation sets how clients can recover from the error. JSON bodies
+// and problem details carry it as the remediation member.
+func (e *Error) WithRemediation(remediation Remediation) *Error {
+	e.remediation = &remediation
+	return e
+}
+
+// Remediation returns the remediation set with WithRemediation, or else that
+// of the wrapped error
+func (e *Error) Remediation() *Remediation {
+	var r interface{ Remediation() *Remediation }
+	if e.remediation == nil && errors.As(e.err, &r) {
+		return r.Remediation()
+	}
+	return e.remediation
+}
+
+// remediationOf returns the remediation of an error implementing
+// Remediation() *Remediation, or nil
+func remediationOf(err HTTPError) *Remediation {
+	var r interface{ Remediation() *Remediation }
+	if errors.As(err, &r) {
+		return r.Remediation()
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+package httperrorfmt
+
+import (
+	"net/http"
+	"net/netip"
+)
+
+// peerAddr returns the address of the peer that sent r, with IPv4-mapped
+// IPv6 addresses unmapped
+func peerAddr(r *http.Request) (netip.Addr, bool) {
+	if addrPort, err := netip.ParseAddrPort(r.RemoteAddr); err == nil {
+		return addrPort.Addr().Unmap(), true
+	}
+	if addr, err := netip.ParseAddr(r.RemoteAddr); err == nil {
+		return addr.Unmap(), true
+	}
+	return netip.Addr{}, false
+}
+
+// fromNetworks reports whether the peer address of r is in one of the networks
+func fromNetworks(r *http.Request, networks []netip.Prefix) bool {
+	if len(networks) == 0 {
+		return false
+	}
+	addr, ok := peerAddr(r)
+	if !ok {
+		return false
+	}
+	for _, network := range networks {
+		if network.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
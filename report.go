@@ -0,0 +1,67 @@
+package httperrorfmt
+
+import (
+	"math/rand/v2"
+	"net/http"
+	"slices"
+)
+
+// Report is what a Reporter gets for an error
+type Report struct {
+	Error   HTTPError
+	Status  int
+	Message string
+	Stack   string
+	// ErrorID is the error's own id, or the id it was stored under, if any
+	ErrorID string
+	// UserID identifies the user the request was made for, if known
+	UserID string
+	// Category is the classification of the error, for routing alerts
+	Category Classification
+}
+
+// Reporter sends errors to an error tracking service such as Sentry
+type Reporter interface {
+	Report(r *http.Request, report Report)
+}
+
+// ReportOptions selects the errors ReportErrors passes on
+type ReportOptions struct {
+	// Statuses limits reporting to these statuses; classes such as 5 cover
+	// 500-599. Empty means 5xx.
+	Statuses []int
+	// SampleRate reports this fraction of the matching errors. Zero means all.
+	SampleRate float64
+	// UserID finds the user of a request
+	UserID func(r *http.Request) string
+}
+
+// ReportErrors returns a hook passing the errors selected by opts to reporter.
+// Add it to a negotiator with OnError or to a formatter with HookedFormatter.
+func ReportErrors(reporter Reporter, opts ReportOptions) Hook {
+	statuses := opts.Statuses
+	if len(statuses) == 0 {
+		statuses = []int{5}
+	}
+	return func(r *http.Request, err HTTPError) {
+		status := err.StatusCode()
+		if !slices.Contains(statuses, status) && !slices.Contains(statuses, status/100) {
+			return
+		}
+		if opts.SampleRate > 0 && rand.Float64() >= opts.SampleRate {
+			return
+		}
+		report := Report{
+			Error:    err,
+			Status:   status,
+			Message:  internalMessage(err),
+			Stack:    stackOf(err),
+			Category: ClassificationOf(err),
+		}
+		report.ErrorID, _ = ownErrorID(err)
+		if opts.UserID != nil {
+			report.UserID = opts.UserID(r)
+		}
+		reporter.Report(r, report)
+	}
+}
@@ -0,0 +1,78 @@
+package httperrorfmt
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+// RequestIDExtractor finds the correlation id of a request so error bodies and
+// responses carry the id that also appears in server logs
+type RequestIDExtractor struct {
+	// Header is the request header holding the id. Empty means X-Request-ID.
+	Header string
+	// ContextKey looks the id up in the request context before the header, for
+	// middleware that stores it there. The value must be a string or fmt.Stringer.
+	ContextKey any
+	// Generate creates ids for requests without one, e.g. UUIDv7 or ULID. Nil
+	// leaves those requests without an id.
+	Generate IDGenerator
+	// ResponseHeader is the response header echoing the id. Empty means X-Request-ID.
+	ResponseHeader string
+}
+
+// RequestID returns the correlation id of r, generating one when configured
+func (e *RequestIDExtractor) RequestID(r *http.Request) string {
+	if e.ContextKey != nil {
+		switch id := r.Context().Value(e.ContextKey).(type) {
+		case string:
+			if id = headerSafe(id); id != "" {
+				return id
+			}
+		case fmt.Stringer:
+			if s := headerSafe(id.String()); s != "" {
+				return s
+			}
+		}
+	}
+	header := e.Header
+	if header == "" {
+		header = "X-Request-ID"
+	}
+	if id := headerSafe(r.Header.Get(header)); id != "" {
+		return id
+	}
+	if e.Generate != nil {
+		return e.Generate.NewID()
+	}
+	return ""
+}
+
+// requestID is the correlation id resolved for a request being formatted
+type requestID struct {
+	id     string
+	header string
+}
+
+// requestIDKey is the request context key for the resolved requestID
+type requestIDKey struct{}
+
+// withRequestID resolves the correlation id of r once, so the response
+// header and the body agree on generated ids
+func withRequestID(r *http.Request, e *RequestIDExtractor) *http.Request {
+	id := e.RequestID(r)
+	if id == "" {
+		return r
+	}
+	header := e.ResponseHeader
+	if header == "" {
+		header = "X-Request-ID"
+	}
+	return r.WithContext(context.WithValue(r.Context(), requestIDKey{}, requestID{id: id, header: header}))
+}
+
+// requestIDFrom returns the correlation id resolved for r, if any
+func requestIDFrom(r *http.Request) (requestID, bool) {
+	id, ok := r.Context().Value(requestIDKey{}).(requestID)
+	return id, ok
+}
@@ -0,0 +1,112 @@
+package httperrorfmt
+
+import (
+	"errors"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy decides how long a client should wait before retrying, given
+// how many attempts it has made so far (starting at 1)
+type RetryPolicy interface {
+	Delay(attempt int) time.Duration
+}
+
+// RetryPolicyFunc adapts an ordinary function to a RetryPolicy
+type RetryPolicyFunc func(attempt int) time.Duration
+
+// Delay calls f(attempt)
+func (f RetryPolicyFunc) Delay(attempt int) time.Duration { return f(attempt) }
+
+// FixedDelay is a policy asking for the same delay on every attempt
+func FixedDelay(d time.Duration) RetryPolicy {
+	return RetryPolicyFunc(func(int) time.Duration { return d })
+}
+
+// ExponentialBackoff doubles the delay with every attempt, starting at Base
+// and capped at Max
+type ExponentialBackoff struct {
+	Base time.Duration
+	// Max caps the delay. Zero means no cap.
+	Max time.Duration
+	// Jitter picks a random delay between half and all of the computed one,
+	// so throttled clients don't retry in lockstep
+	Jitter bool
+}
+
+// Delay implements RetryPolicy
+func (b ExponentialBackoff) Delay(attempt int) time.Duration {
+	d := b.Base
+	for i := 1; i < attempt && (b.Max == 0 || d < b.Max); i++ {
+		d *= 2
+	}
+	if b.Max > 0 {
+		d = min(d, b.Max)
+	}
+	if b.Jitter && d > 1 {
+		d = d/2 + rand.N(d/2)
+	}
+	return d
+}
+
+// WithRetryAfter sets Retry-After to a delay, in whole seconds rounded up
+func (e *Error) WithRetryAfter(d time.Duration) *Error {
+	return e.WithHeader("Retry-After", strconv.FormatInt(int64((max(d, 0)+time.Second-1)/time.Second), 10))
+}
+
+// WithRetryAt sets Retry-After to a point in time, as an HTTP date
+func (e *Error) WithRetryAt(t time.Time) *Error {
+	return e.WithHeader("Retry-After", t.UTC().Format(http.TimeFormat))
+}
+
+// WithRetry sets Retry-After to the delay policy asks for the given attempt
+func (e *Error) WithRetry(policy RetryPolicy, attempt int) *Error {
+	return e.WithRetryAfter(policy.Delay(attempt))
+}
+
+// TooManyRequests creates a 429 error asking the client to retry after the
+// delay policy gives for the attempt. A nil policy leaves Retry-After out.
+func TooManyRequests(policy RetryPolicy, attempt int) *Error {
+	e := New(http.StatusTooManyRequests, "Too many requests")
+	if policy != nil {
+		e.WithRetry(policy, attempt)
+	}
+	return e
+}
+
+// ServiceUnavailable creates a 503 error asking the client to retry after the
+// delay policy gives for the attempt. A nil policy leaves Retry-After out.
+func ServiceUnavailable(policy RetryPolicy, attempt int) *Error {
+	e := New(http.StatusServiceUnavailable, "The service is temporarily unavailable")
+	if policy != nil {
+		e.WithRetry(policy, attempt)
+	}
+	return e
+}
+
+// retryAfterOf returns how long the client should wait before retrying, from
+// RetryAfter() time.Duration when the error implements it, or else from its
+// Retry-After header
+func retryAfterOf(err HTTPError) (time.Duration, bool) {
+	var r interface{ RetryAfter() time.Duration }
+	if errors.As(err, &r) {
+		return r.RetryAfter(), true
+	}
+	for key, value := range err.Headers() {
+		if http.CanonicalHeaderKey(key) == "Retry-After" {
+			return RetryAfter(http.Header{"Retry-After": {value}})
+		}
+	}
+	return 0, false
+}
+
+// retryAfterSeconds returns the retry delay in whole seconds for bodies, or 0
+func retryAfterSeconds(err HTTPError) int64 {
+	d, ok := retryAfterOf(err)
+	if !ok {
+		return 0
+	}
+	return int64((d + time.Second - 1) / time.Second)
+}
@@ -0,0 +1,80 @@
+package httperrorfmt
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+)
+
+// RetryHints tells client SDKs whether a request may be retried
+// automatically. Unset hints are left out of responses.
+type RetryHints struct {
+	// Retryable is whether sending the same request again may succeed
+	Retryable *bool
+	// IdempotencySafe is whether the request had no effect, so retrying it is
+	// safe even for methods that aren't idempotent, such as POST
+	IdempotencySafe *bool
+}
+
+// WithRetryable sets whether sending the same request again may succeed.
+// Responses carry it as the Retryable header and the retryable body member.
+func (e *Error) WithRetryable(retryable bool) *Error {
+	e.retryHints.Retryable = &retryable
+	return e
+}
+
+// WithIdempotencySafe sets whether the request had no effect, so clients may
+// retry it even when its method isn't idempotent. Responses carry it as the
+// Idempotency-Safe header and the idempotency_safe body member.
+func (e *Error) WithIdempotencySafe(safe bool) *Error {
+	e.retryHints.IdempotencySafe = &safe
+	return e
+}
+
+// RetryHints returns the hints set with WithRetryable and WithIdempotencySafe.
+// Hints left unset are taken from the wrapped error.
+func (e *Error) RetryHints() RetryHints {
+	hints := e.retryHints
+	var h interface{ RetryHints() RetryHints }
+	if (hints.Retryable == nil || hints.IdempotencySafe == nil) && errors.As(e.err, &h) {
+		wrapped := h.RetryHints()
+		if hints.Retryable == nil {
+			hints.Retryable = wrapped.Retryable
+		}
+		if hints.IdempotencySafe == nil {
+			hints.IdempotencySafe = wrapped.IdempotencySafe
+		}
+	}
+	return hints
+}
+
+// retryHintsOf returns the hints of an error implementing RetryHints() RetryHints
+func retryHintsOf(err HTTPError) RetryHints {
+	var h interface{ RetryHints() RetryHints }
+	if errors.As(err, &h) {
+		return h.RetryHints()
+	}
+	return RetryHints{}
+}
+
+// setRetryHintHeaders sends the retry hints that are set as headers
+func setRetryHintHeaders(header http.Header, hints RetryHints) {
+	if hints.Retryable != nil {
+		header.Set("Retryable", strconv.FormatBool(*hints.Retryable))
+	}
+	if hints.IdempotencySafe != nil {
+		header.Set("Idempotency-Safe", strconv.FormatBool(*hints.IdempotencySafe))
+	}
+}
+
+// parseRetryHints reads the retry hints of response headers
+func parseRetryHints(header http.Header) RetryHints {
+	var hints RetryHints
+	if retryable, err := strconv.ParseBool(header.Get("Retryable")); err == nil {
+		hints.Retryable = &retryable
+	}
+	if safe, err := strconv.ParseBool(header.Get("Idempotency-Safe")); err == nil {
+		hints.IdempotencySafe = &safe
+	}
+	return hints
+}
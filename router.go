@@ -0,0 +1,178 @@
+package httperrorfmt
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync/atomic"
+)
+
+// Router picks the formatter for an error by the request's route, so sections
+// of a large application can have different error surfaces
+type Router struct {
+	routes []route
+	// Default formats errors for requests no route matches
+	Default Formatter
+
+	overrides atomic.Pointer[[]override]
+}
+
+// route binds a pattern to a formatter
+type route struct {
+	pattern   string
+	method    string
+	segments  []string
+	prefix    bool
+	formatter Formatter
+}
+
+// NewRouter creates a router that falls back to fallback
+func NewRouter(fallback Formatter) *Router {
+	return &Router{Default: fallback}
+}
+
+// Handle binds formatter to a pattern. Patterns use http.ServeMux syntax: an
+// optional method, {name} for one path segment, {name...} or a trailing slash
+// for the rest of the path. A trailing "/*" is accepted as a prefix too, so
+// "/api/*" and "/api/" are the same. Requests routed by a ServeMux with the very
+// same pattern match it directly through Request.Pattern.
+func (rt *Router) Handle(pattern string, formatter Formatter) *Router {
+	rt.routes = append(rt.routes, parseRoute(pattern, formatter))
+	return rt
+}
+
+// Format implements Formatter interface by dispatching on the request's route.
+// The most specific matching pattern wins. Loaded overrides apply first.
+func (rt *Router) Format(w http.ResponseWriter, r *http.Request, err HTTPError) {
+	r, err = normalize(r, err)
+	if o := rt.matchOverride(r, err); o != nil {
+		r, err = o.apply(r, err)
+		if formatter := o.formatter(r); formatter != nil {
+			addVary(w.Header(), "Accept")
+			formatter.Format(w, r, err)
+			return
+		}
+	}
+	if formatter := rt.match(r); formatter != nil {
+		formatter.Format(w, r, err)
+		return
+	}
+	OrDefault(rt.Default).Format(w, r, err)
+}
+
+// Warmup warms every routed formatter and the default
+func (rt *Router) Warmup() error {
+	var errs []error
+	for _, route := range rt.routes {
+		if err := warmFormatter(route.formatter); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", route.pattern, err))
+		}
+	}
+	if rt.Default != nil {
+		if err := warmFormatter(rt.Default); err != nil {
+			errs = append(errs, fmt.Errorf("default: %w", err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// match returns the formatter of the most specific route matching r
+func (rt *Router) match(r *http.Request) Formatter {
+	if rt == nil {
+		return nil
+	}
+	if r.Pattern != "" {
+		for _, route := range rt.routes {
+			if route.pattern == r.Pattern {
+				return route.formatter
+			}
+		}
+	}
+	if r.URL == nil {
+		return nil
+	}
+
+	path := splitPath(r.URL.Path)
+	var best *route
+	bestScore := -1
+	for i := range rt.routes {
+		route := &rt.routes[i]
+		if !route.matches(r.Method, path) {
+			continue
+		}
+		if score := route.specificity(); score > bestScore {
+			best, bestScore = route, score
+		}
+	}
+	if best == nil {
+		return nil
+	}
+	return best.formatter
+}
+
+// parseRoute splits a pattern into its method and path segments
+func parseRoute(pattern string, formatter Formatter) route {
+	rt := route{pattern: pattern, formatter: formatter}
+	path := pattern
+	if method, rest, ok := strings.Cut(pattern, " "); ok {
+		rt.method, path = method, strings.TrimSpace(rest)
+	}
+	path = strings.TrimSuffix(path, "*")
+	if strings.HasSuffix(path, "/") {
+		rt.prefix = true
+	}
+	rt.segments = splitPath(path)
+	if n := len(rt.segments); n > 0 && strings.HasSuffix(rt.segments[n-1], "...}") {
+		rt.segments = rt.segments[:n-1]
+		rt.prefix = true
+	}
+	return rt
+}
+
+// matches reports whether the route matches a method and a split path
+func (rt *route) matches(method string, path []string) bool {
+	if rt.method != "" && rt.method != method && !(rt.method == http.MethodGet && method == http.MethodHead) {
+		return false
+	}
+	if len(path) < len(rt.segments) || (!rt.prefix && len(path) != len(rt.segments)) {
+		return false
+	}
+	for i, segment := range rt.segments {
+		if !isWildcard(segment) && segment != path[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// specificity ranks routes: exact paths beat prefixes, then more literal
+// segments win, then a method restriction
+func (rt *route) specificity() int {
+	score := 0
+	for _, segment := range rt.segments {
+		if isWildcard(segment) {
+			score += 2
+		} else {
+			score += 3
+		}
+	}
+	score *= 4
+	if !rt.prefix {
+		score += 2
+	}
+	if rt.method != "" {
+		score++
+	}
+	return score
+}
+
+// isWildcard reports whether a pattern segment is a {name} wildcard
+func isWildcard(segment string) bool {
+	return strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}")
+}
+
+// splitPath splits a path into its non-empty segments
+func splitPath(path string) []string {
+	return strings.FieldsFunc(path, func(c rune) bool { return c == '/' })
+}
@@ -0,0 +1,204 @@
+package httperrorfmt
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"maps"
+	"slices"
+	"text/template/parse"
+	"time"
+)
+
+// DefaultBlockedFuncs are the template functions VetTemplate rejects when
+// TemplateLimits.BlockedFuncs is nil. call invokes arbitrary functions found in
+// the template data.
+var DefaultBlockedFuncs = []string{"call"}
+
+// TemplateLimits bounds what an error page template may do. It is meant for
+// deployments where templates are supplied by tenants rather than operators.
+type TemplateLimits struct {
+	// MaxOutput is the largest page in bytes a template may render. Zero means no limit.
+	MaxOutput int
+	// Timeout bounds rendering. The page is abandoned once it passes, and the
+	// template is stopped at its next write. Zero means no limit.
+	Timeout time.Duration
+	// BlockedFuncs are the functions a template may not use. Nil means DefaultBlockedFuncs.
+	BlockedFuncs []string
+}
+
+// ErrTemplateLimit is returned when rendering exceeds the output size or timeout
+var ErrTemplateLimit = errors.New("httperrorfmt: template exceeded its limits")
+
+// enabled reports whether any execution limit is set
+func (l TemplateLimits) enabled() bool {
+	return l.MaxOutput > 0 || l.Timeout > 0
+}
+
+// execute renders the template into memory within the limits and copies the
+// result to w only when rendering succeeded. With a timeout the template runs
+// under a watchdog, so loops that write nothing can't hold up the response.
+func (l TemplateLimits) execute(w io.Writer, t *template.Template, name string, data any) error {
+	lw := &limitedWriter{max: l.MaxOutput}
+	run := func() error {
+		if name != "" {
+			return t.ExecuteTemplate(lw, name, data)
+		}
+		return t.Execute(lw, data)
+	}
+
+	var err error
+	if l.Timeout <= 0 {
+		err = run()
+	} else {
+		lw.deadline = time.Now().Add(l.Timeout)
+		done := make(chan error, 1)
+		go func() { done <- run() }()
+		timer := time.NewTimer(l.Timeout)
+		defer timer.Stop()
+		select {
+		case err = <-done:
+		case <-timer.C:
+			return ErrTemplateLimit
+		}
+	}
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(lw.buf.Bytes())
+	return err
+}
+
+// limitedWriter buffers output and fails once a size or time limit is passed
+type limitedWriter struct {
+	buf      bytes.Buffer
+	max      int
+	deadline time.Time
+}
+
+// Write implements io.Writer. The buffer is only read once the template has
+// returned, so writes from an abandoned template don't race with the reader.
+func (w *limitedWriter) Write(p []byte) (int, error) {
+	if w.max > 0 && w.buf.Len()+len(p) > w.max {
+		return 0, ErrTemplateLimit
+	}
+	if !w.deadline.IsZero() && time.Now().After(w.deadline) {
+		return 0, ErrTemplateLimit
+	}
+	return w.buf.Write(p)
+}
+
+// VetTemplate reports constructs a tenant-supplied template may not use: calls
+// to blocked functions, ranges over integer literals, which can loop without
+// writing anything, and templates that invoke themselves, directly or through
+// others. Vet templates before their first execution.
+func VetTemplate(t *template.Template, limits TemplateLimits) error {
+	blocked := limits.BlockedFuncs
+	if blocked == nil {
+		blocked = DefaultBlockedFuncs
+	}
+
+	var problems []error
+	invokes := make(map[string][]string)
+	for _, tmpl := range t.Templates() {
+		if tmpl.Tree == nil || tmpl.Tree.Root == nil {
+			continue
+		}
+		name := tmpl.Name()
+		walkTemplate(tmpl.Tree.Root, func(node parse.Node) {
+			switch n := node.(type) {
+			case *parse.IdentifierNode:
+				if slices.Contains(blocked, n.Ident) {
+					problems = append(problems, fmt.Errorf("httperrorfmt: template %q uses blocked function %q", name, n.Ident))
+				}
+			case *parse.TemplateNode:
+				invokes[name] = append(invokes[name], n.Name)
+			case *parse.RangeNode:
+				if rangesOverInteger(n.Pipe) {
+					problems = append(problems, fmt.Errorf("httperrorfmt: template %q ranges over an integer literal", name))
+				}
+			}
+		})
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(invokes)) {
+		if reaches(invokes, name, name, make(map[string]bool)) {
+			problems = append(problems, fmt.Errorf("httperrorfmt: template %q invokes itself", name))
+		}
+	}
+	return errors.Join(problems...)
+}
+
+// rangesOverInteger reports whether a range pipeline is an integer literal
+func rangesOverInteger(pipe *parse.PipeNode) bool {
+	if pipe == nil || len(pipe.Cmds) != 1 || len(pipe.Cmds[0].Args) != 1 {
+		return false
+	}
+	number, ok := pipe.Cmds[0].Args[0].(*parse.NumberNode)
+	return ok && number.IsInt
+}
+
+// reaches reports whether template from invokes target, following invocations
+func reaches(invokes map[string][]string, from, target string, seen map[string]bool) bool {
+	for _, next := range invokes[from] {
+		if next == target {
+			return true
+		}
+		if !seen[next] {
+			seen[next] = true
+			if reaches(invokes, next, target, seen) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// walkTemplate calls visit for every node below node
+func walkTemplate(node parse.Node, visit func(parse.Node)) {
+	if node == nil {
+		return
+	}
+	visit(node)
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			walkTemplate(child, visit)
+		}
+	case *parse.ActionNode:
+		walkTemplate(n.Pipe, visit)
+	case *parse.PipeNode:
+		if n == nil {
+			return
+		}
+		for _, cmd := range n.Cmds {
+			walkTemplate(cmd, visit)
+		}
+	case *parse.CommandNode:
+		for _, arg := range n.Args {
+			walkTemplate(arg, visit)
+		}
+	case *parse.ChainNode:
+		walkTemplate(n.Node, visit)
+	case *parse.IfNode:
+		walkBranch(&n.BranchNode, visit)
+	case *parse.RangeNode:
+		walkBranch(&n.BranchNode, visit)
+	case *parse.WithNode:
+		walkBranch(&n.BranchNode, visit)
+	case *parse.TemplateNode:
+		walkTemplate(n.Pipe, visit)
+	}
+}
+
+// walkBranch walks the pipeline and both lists of an if, range or with
+func walkBranch(n *parse.BranchNode, visit func(parse.Node)) {
+	walkTemplate(n.Pipe, visit)
+	walkTemplate(n.List, visit)
+	walkTemplate(n.ElseList, visit)
+}
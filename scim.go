@@ -0,0 +1,51 @@
+package httperrorfmt
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+)
+
+// SCIMErrorSchema is the schema URI of RFC 7644 error responses
+const SCIMErrorSchema = "urn:ietf:params:scim:api:messages:2.0:Error"
+
+// SCIMFormatter formats errors as RFC 7644 SCIM error responses
+type SCIMFormatter struct {
+	PrettyPrint bool
+}
+
+// SCIMErrorResponse represents a SCIM error response
+type SCIMErrorResponse struct {
+	Schemas  []string `json:"schemas"`
+	ScimType string   `json:"scimType,omitempty"`
+	Detail   string   `json:"detail,omitempty"`
+	Status   string   `json:"status"`
+}
+
+// Format implements Formatter interface for SCIM error responses.
+// Errors implementing ScimType() string set the scimType member.
+func (f *SCIMFormatter) Format(w http.ResponseWriter, r *http.Request, err HTTPError) {
+	r, err = normalize(r, err)
+	writeHeader(w, r, err, "application/scim+json")
+
+	response := SCIMErrorResponse{
+		Schemas: []string{SCIMErrorSchema},
+		Detail:  publicMessage(err),
+		// SCIM transmits the status as a string
+		Status: strconv.Itoa(err.StatusCode()),
+	}
+	var t interface{ ScimType() string }
+	if errors.As(err, &t) {
+		response.ScimType = t.ScimType()
+	}
+
+	var data []byte
+	if f.PrettyPrint {
+		data, _ = json.MarshalIndent(response, "", "  ")
+	} else {
+		data, _ = json.Marshal(response)
+	}
+
+	w.Write(data)
+}
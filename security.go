@@ -0,0 +1,69 @@
+package httperrorfmt
+
+import (
+	"mime"
+	"net/http"
+)
+
+// DefaultErrorPageCSP only allows the inline styles of the built-in error
+// page, and keeps the page from being framed
+const DefaultErrorPageCSP = "default-src 'none'; style-src 'unsafe-inline'; img-src data:; base-uri 'none'; form-action 'none'; frame-ancestors 'none'"
+
+// SecureFormatter adds security headers to error responses, which often
+// bypass the application's own security middleware: X-Content-Type-Options
+// and Referrer-Policy on every response, and a restrictive
+// Content-Security-Policy on HTML pages
+type SecureFormatter struct {
+	Formatter Formatter
+	// ContentSecurityPolicy is sent with HTML responses. Empty means DefaultErrorPageCSP.
+	ContentSecurityPolicy string
+	// ReferrerPolicy empty means no-referrer
+	ReferrerPolicy string
+}
+
+// Format implements Formatter interface by adding security headers and
+// delegating to Formatter
+func (f *SecureFormatter) Format(w http.ResponseWriter, r *http.Request, err HTTPError) {
+	r, err = normalize(r, err)
+	referrer := f.ReferrerPolicy
+	if referrer == "" {
+		referrer = "no-referrer"
+	}
+	csp := f.ContentSecurityPolicy
+	if csp == "" {
+		csp = DefaultErrorPageCSP
+	}
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Referrer-Policy", referrer)
+	OrDefault(f.Formatter).Format(&secureWriter{ResponseWriter: w, csp: csp}, r, err)
+}
+
+// secureWriter adds a Content-Security-Policy once the response turns out to be HTML
+type secureWriter struct {
+	http.ResponseWriter
+	csp         string
+	wroteHeader bool
+}
+
+// WriteHeader adds the policy to HTML responses before sending the header
+func (w *secureWriter) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		mediaType, _, _ := mime.ParseMediaType(w.Header().Get("Content-Type"))
+		if mediaType == "text/html" || mediaType == "application/xhtml+xml" {
+			w.Header().Set("Content-Security-Policy", w.csp)
+		}
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// Write sends the header first if needed
+func (w *secureWriter) Write(p []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+// Unwrap returns the underlying ResponseWriter
+func (w *secureWriter) Unwrap() http.ResponseWriter { return w.ResponseWriter }
@@ -0,0 +1,67 @@
+// Package sentryerr reports errors formatted by httperrorfmt to Sentry
+package sentryerr
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/perbu/httperrorfmt"
+)
+
+// Reporter is an httperrorfmt.Reporter capturing errors as Sentry events
+type Reporter struct {
+	// Hub captures the events of requests without a hub of their own, as set
+	// by the sentryhttp middleware. Nil means sentry.CurrentHub().
+	Hub *sentry.Hub
+}
+
+// New creates a reporter falling back to hub
+func New(hub *sentry.Hub) *Reporter {
+	return &Reporter{Hub: hub}
+}
+
+// Report implements httperrorfmt.Reporter. The event carries the request, the
+// status, the error id and user when known, and the stack of the error.
+func (rep *Reporter) Report(r *http.Request, report httperrorfmt.Report) {
+	hub := sentry.GetHubFromContext(r.Context())
+	if hub == nil {
+		hub = rep.Hub
+	}
+	if hub == nil {
+		hub = sentry.CurrentHub()
+	}
+
+	hub.WithScope(func(scope *sentry.Scope) {
+		scope.SetRequest(r)
+		scope.SetTag("http.status_code", strconv.Itoa(report.Status))
+		if code, ok := errorCode(report.Error); ok {
+			scope.SetTag("error_code", code)
+		}
+		if report.ErrorID != "" {
+			scope.SetTag("error_id", report.ErrorID)
+		}
+		if report.UserID != "" {
+			scope.SetUser(sentry.User{ID: report.UserID})
+		}
+		if report.Stack != "" {
+			scope.SetContext("stack", sentry.Context{"trace": report.Stack})
+		}
+		hub.CaptureException(report.Error)
+	})
+}
+
+// errorCode returns the application error code of err, if any, including
+// internal codes that clients never see
+func errorCode(err error) (string, bool) {
+	var i interface{ InternalErrorCode() string }
+	if errors.As(err, &i) && i.InternalErrorCode() != "" {
+		return i.InternalErrorCode(), true
+	}
+	var c interface{ ErrorCode() string }
+	if !errors.As(err, &c) || c.ErrorCode() == "" {
+		return "", false
+	}
+	return c.ErrorCode(), true
+}
@@ -0,0 +1,54 @@
+package httperrorfmt
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// SOAPFormatter formats errors as SOAP 1.2 faults
+type SOAPFormatter struct {
+	// Lang is the xml:lang of the fault reason, "en" when empty
+	Lang string
+}
+
+// Format implements Formatter interface for SOAP fault responses. Client errors
+// become env:Sender faults and server errors env:Receiver faults.
+func (f *SOAPFormatter) Format(w http.ResponseWriter, r *http.Request, err HTTPError) {
+	r, err = normalize(r, err)
+	writeHeader(w, r, err, "application/soap+xml; charset=utf-8")
+
+	code := "env:Receiver"
+	if err.StatusCode() < 500 {
+		code = "env:Sender"
+	}
+	lang := f.Lang
+	if lang == "" {
+		lang = "en"
+	}
+
+	var reason strings.Builder
+	xml.EscapeText(&reason, []byte(publicMessage(err)))
+
+	w.Write([]byte(xml.Header))
+	fmt.Fprintf(w, `<env:Envelope xmlns:env="http://www.w3.org/2003/05/soap-envelope">
+    <env:Body>
+        <env:Fault>
+            <env:Code>
+                <env:Value>%s</env:Value>
+            </env:Code>
+            <env:Reason>
+                <env:Text xml:lang="%s">%s</env:Text>
+            </env:Reason>
+        </env:Fault>
+    </env:Body>
+</env:Envelope>`, code, xmlAttr(lang), reason.String())
+}
+
+// xmlAttr escapes a value for use inside an XML attribute
+func xmlAttr(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
@@ -0,0 +1,56 @@
+package httperrorfmt
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strconv"
+)
+
+// SoftErrorFormatter formats errors as 200 responses with a
+// {"ok": false, "error": {...}} body, for legacy partners that treat any
+// non-2xx status as a transport failure. The real status is sent in a header.
+// Hooks and post-processors still see the error with its real status.
+type SoftErrorFormatter struct {
+	// JSON renders the "error" member, always without JSONP. Nil means a plain
+	// JSONFormatter.
+	JSON *JSONFormatter
+	// StatusHeader carries the real status. Empty means X-Status.
+	StatusHeader string
+}
+
+// SoftErrorResponse represents a soft error response
+type SoftErrorResponse struct {
+	OK    bool            `json:"ok"`
+	Error json.RawMessage `json:"error"`
+}
+
+// Format implements Formatter interface for soft error responses
+func (f *SoftErrorFormatter) Format(w http.ResponseWriter, r *http.Request, err HTTPError) {
+	r, err = normalize(r, err)
+	formatter := &JSONFormatter{}
+	if f.JSON != nil {
+		// A JSONP body can't be embedded as JSON
+		copied := *f.JSON
+		copied.JSONPCallback = ""
+		formatter = &copied
+	}
+	buf := newResponseBuffer(w.Header())
+	formatter.Format(buf, r, err)
+	resp := buf.response()
+
+	name := f.StatusHeader
+	if name == "" {
+		name = "X-Status"
+	}
+	resp.Header.Set(name, strconv.Itoa(resp.Status))
+	resp.Status = http.StatusOK
+
+	response := SoftErrorResponse{Error: bytes.TrimSpace(resp.Body)}
+	if formatter.PrettyPrint {
+		resp.Body, _ = json.MarshalIndent(response, "", "  ")
+	} else {
+		resp.Body, _ = json.Marshal(response)
+	}
+	writeResponse(w, resp)
+}
@@ -0,0 +1,123 @@
+package httperrorfmt
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// terminalAgents are User-Agent prefixes of command line HTTP clients
+var terminalAgents = []string{"curl/", "wget/", "httpie/", "xh/", "aria2/"}
+
+// IsTerminalClient reports whether the request comes from a command line
+// client such as curl, wget or HTTPie. Combine it with ConditionalFormatter to
+// route those clients to a TerminalFormatter.
+func IsTerminalClient(r *http.Request) bool {
+	agent := strings.ToLower(r.UserAgent())
+	for _, prefix := range terminalAgents {
+		if strings.HasPrefix(agent, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// TerminalFormatter formats errors as an aligned text block for people
+// calling APIs from a shell
+type TerminalFormatter struct {
+	// Color adds ANSI colors: red for 5xx, yellow for 4xx
+	Color bool
+	// RequestIDHeader names the request header holding the request id. Empty
+	// means X-Request-Id.
+	RequestIDHeader string
+}
+
+// ANSI escape sequences used by TerminalFormatter
+const (
+	ansiReset  = "\x1b[0m"
+	ansiBold   = "\x1b[1m"
+	ansiDim    = "\x1b[2m"
+	ansiRed    = "\x1b[31m"
+	ansiYellow = "\x1b[33m"
+)
+
+// Format implements Formatter interface for terminal clients
+func (f *TerminalFormatter) Format(w http.ResponseWriter, r *http.Request, err HTTPError) {
+	r, err = normalize(r, err)
+	writeHeader(w, r, err, "text/plain; charset=utf-8")
+
+	d := collectDetails(r, err, settingsFrom(r).features)
+	header := f.RequestIDHeader
+	if header == "" {
+		header = "X-Request-Id"
+	}
+	requestID := d.RequestID
+	if requestID == "" {
+		requestID = r.Header.Get(header)
+	}
+	rows := [][2]string{
+		{"Message", publicMessage(err)},
+		{"Error ID", d.ErrorID},
+		{"Request ID", requestID},
+		{"Docs", d.DocURL},
+	}
+	for _, cause := range d.Causes {
+		label := "Cause"
+		if cause.Field != "" {
+			label = cause.Field
+		}
+		rows = append(rows, [2]string{label, cause.Message})
+	}
+	// Values come from errors and requests; control sequences in them could
+	// take over the client's terminal
+	for i := range rows {
+		rows[i] = [2]string{terminalSafe(rows[i][0]), terminalSafe(rows[i][1])}
+	}
+
+	width := 0
+	for _, row := range rows {
+		if row[1] != "" {
+			width = max(width, len(row[0]))
+		}
+	}
+
+	var b strings.Builder
+	status := fmt.Sprintf("%d %s", err.StatusCode(), statusText(err.StatusCode()))
+	b.WriteString("\n  " + f.paint(status, ansiBold+f.statusColor(err.StatusCode())) + "\n\n")
+	for _, row := range rows {
+		if row[1] == "" {
+			continue
+		}
+		label := fmt.Sprintf("%-*s", width, row[0])
+		b.WriteString("  " + f.paint(label, ansiDim) + "  " + row[1] + "\n")
+	}
+	b.WriteString("\n")
+	w.Write([]byte(b.String()))
+}
+
+// terminalSafe removes C0 and C1 control characters, which start escape
+// sequences, and DEL from s
+func terminalSafe(s string) string {
+	return strings.Map(func(c rune) rune {
+		if c < 0x20 || c >= 0x7f && c <= 0x9f {
+			return -1
+		}
+		return c
+	}, s)
+}
+
+// statusColor returns the color for a status code
+func (f *TerminalFormatter) statusColor(status int) string {
+	if status >= 500 {
+		return ansiRed
+	}
+	return ansiYellow
+}
+
+// paint wraps s in an ANSI style when colors are on
+func (f *TerminalFormatter) paint(s, style string) string {
+	if !f.Color {
+		return s
+	}
+	return style + s + ansiReset
+}
@@ -0,0 +1,117 @@
+package httperrorfmt
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// StatusClientClosedRequest is the nginx status for requests the client gave
+// up on before the response was ready
+const StatusClientClosedRequest = 499
+
+// ContextError converts context.DeadlineExceeded to a 504 and
+// context.Canceled to a 499, keeping the error for logs. Other errors give nil,
+// so it can be added to an ErrorMapper:
+//
+//	mapper.Add(httperrorfmt.ContextError)
+func ContextError(err error) HTTPError {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return Wrap(err, http.StatusGatewayTimeout, "The request timed out")
+	case errors.Is(err, context.Canceled):
+		return Wrap(err, StatusClientClosedRequest, "The request was canceled")
+	default:
+		return nil
+	}
+}
+
+// TimeoutHandler is http.TimeoutHandler with the timeout response rendered by
+// f: h runs with a time limit of dt and requests that take longer get a 503.
+// Like http.TimeoutHandler, the response of h is buffered and writes after the
+// timeout fail with http.ErrHandlerTimeout. A nil formatter means Default.
+func TimeoutHandler(h http.Handler, dt time.Duration, f Formatter) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), dt)
+		defer cancel()
+
+		tw := &timeoutWriter{header: make(http.Header)}
+		done := make(chan struct{})
+		panicked := make(chan any, 1)
+		go func() {
+			defer func() {
+				if v := recover(); v != nil {
+					panicked <- v
+				}
+			}()
+			h.ServeHTTP(tw, r.WithContext(ctx))
+			close(done)
+		}()
+
+		select {
+		case v := <-panicked:
+			panic(v)
+		case <-done:
+			tw.mu.Lock()
+			defer tw.mu.Unlock()
+			header := w.Header()
+			for key, values := range tw.header {
+				header[key] = values
+			}
+			if tw.status == 0 {
+				tw.status = http.StatusOK
+			}
+			w.WriteHeader(tw.status)
+			w.Write(tw.body.Bytes())
+		case <-ctx.Done():
+			tw.mu.Lock()
+			defer tw.mu.Unlock()
+			tw.timedOut = true
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				OrDefault(f).Format(w, r, Wrap(http.ErrHandlerTimeout, http.StatusServiceUnavailable, "The request took too long"))
+			}
+		}
+	})
+}
+
+// timeoutWriter buffers the response of a handler running under a time limit
+type timeoutWriter struct {
+	mu       sync.Mutex
+	header   http.Header
+	body     bytes.Buffer
+	status   int
+	timedOut bool
+}
+
+func (tw *timeoutWriter) Header() http.Header { return tw.header }
+
+func (tw *timeoutWriter) Write(p []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	if tw.status == 0 {
+		tw.status = http.StatusOK
+	}
+	return tw.body.Write(p)
+}
+
+func (tw *timeoutWriter) WriteHeader(status int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut || tw.status != 0 {
+		return
+	}
+	tw.status = status
+}
+
+// clientGone reports whether the request was canceled, usually because the
+// client disconnected, so its response would never be read. Requests whose
+// deadline passed still get their response, such as a 504.
+func clientGone(r *http.Request) bool {
+	return errors.Is(r.Context().Err(), context.Canceled)
+}
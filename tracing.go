@@ -0,0 +1,35 @@
+package httperrorfmt
+
+import (
+	"net/http"
+	"runtime/trace"
+	"strconv"
+)
+
+// SetTracing turns on runtime/trace annotations. While an execution trace is
+// being recorded, every formatted error gets an "httperrorfmt.Format" task with
+// its status and code logged, and regions for selecting the formatter,
+// rendering and post-processing.
+func (cn *ContentNegotiator) SetTracing(enabled bool) *ContentNegotiator {
+	cn.tracing = enabled
+	return cn
+}
+
+// startTrace creates the task for formatting err and binds it to the request
+func startTrace(r *http.Request, err HTTPError) (*http.Request, *trace.Task) {
+	ctx, task := trace.NewTask(r.Context(), "httperrorfmt.Format")
+	trace.Log(ctx, "status", strconv.Itoa(err.StatusCode()))
+	if code := internalErrorCode(err); code != "" {
+		trace.Log(ctx, "code", code)
+	}
+	return r.WithContext(ctx), task
+}
+
+// traceRegion starts a trace region when tracing is on and returns the
+// function ending it
+func traceRegion(r *http.Request, name string) func() {
+	if !settingsFrom(r).tracing || !trace.IsEnabled() {
+		return func() {}
+	}
+	return trace.StartRegion(r.Context(), name).End
+}
@@ -0,0 +1,69 @@
+package httperrorfmt
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// TusVersion is the tus protocol version announced in Tus-Resumable
+const TusVersion = "1.0.0"
+
+// StatusChecksumMismatch is the tus checksum extension's status for corrupted chunks
+const StatusChecksumMismatch = 460
+
+// newTusError creates an error carrying the Tus-Resumable header every tus response needs
+func newTusError(status int, message string) *Error {
+	return New(status, message).WithHeader("Tus-Resumable", TusVersion)
+}
+
+// TusOffsetMismatch creates the 409 sent when a PATCH offset doesn't match the
+// upload, reporting the current offset in Upload-Offset
+func TusOffsetMismatch(offset int64) *Error {
+	return newTusError(http.StatusConflict, "Upload-Offset does not match the current offset of the upload").
+		WithHeader("Upload-Offset", strconv.FormatInt(offset, 10))
+}
+
+// TusUnsupportedVersion creates the 412 sent for an unsupported Tus-Resumable
+// version, listing the supported versions in Tus-Version
+func TusUnsupportedVersion(supported ...string) *Error {
+	if len(supported) == 0 {
+		supported = []string{TusVersion}
+	}
+	return newTusError(http.StatusPreconditionFailed, "Unsupported tus protocol version").
+		WithHeader("Tus-Version", strings.Join(supported, ","))
+}
+
+// TusChecksumMismatch creates the 460 sent when a chunk fails its Upload-Checksum
+func TusChecksumMismatch() *Error {
+	return newTusError(StatusChecksumMismatch, "Upload-Checksum does not match the received data")
+}
+
+// TusUnsupportedChecksum creates the 400 sent for an unsupported checksum
+// algorithm, listing the supported ones in Tus-Checksum-Algorithm
+func TusUnsupportedChecksum(supported ...string) *Error {
+	return newTusError(http.StatusBadRequest, "Unsupported checksum algorithm").
+		WithHeader("Tus-Checksum-Algorithm", strings.Join(supported, ","))
+}
+
+// TusUploadTooLarge creates the 413 sent when an upload exceeds the maximum size,
+// reported in Tus-Max-Size
+func TusUploadTooLarge(maxSize int64) *Error {
+	return newTusError(http.StatusRequestEntityTooLarge, "Upload exceeds the maximum size").
+		WithHeader("Tus-Max-Size", strconv.FormatInt(maxSize, 10))
+}
+
+// TusInvalidContentType creates the 415 sent when a PATCH isn't application/offset+octet-stream
+func TusInvalidContentType() *Error {
+	return newTusError(http.StatusUnsupportedMediaType, "Content-Type must be application/offset+octet-stream")
+}
+
+// TusUploadNotFound creates the 404 sent for an unknown upload
+func TusUploadNotFound() *Error {
+	return newTusError(http.StatusNotFound, "Upload not found")
+}
+
+// TusUploadGone creates the 410 sent for an expired or terminated upload
+func TusUploadGone() *Error {
+	return newTusError(http.StatusGone, "Upload is no longer available")
+}
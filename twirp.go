@@ -0,0 +1,104 @@
+package httperrorfmt
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+// TwirpFormatter formats errors as Twirp JSON error bodies
+type TwirpFormatter struct{}
+
+// TwirpErrorResponse represents a Twirp error body
+type TwirpErrorResponse struct {
+	Code string            `json:"code"`
+	Msg  string            `json:"msg"`
+	Meta map[string]string `json:"meta,omitempty"`
+}
+
+// Format implements Formatter interface for Twirp error responses. The Twirp code
+// comes from TwirpCode() string when the error implements it, otherwise from the
+// status; the response status is the one Twirp documents for that code. Meta is
+// taken from Metadata() map[string]string.
+func (f *TwirpFormatter) Format(w http.ResponseWriter, r *http.Request, err HTTPError) {
+	r, err = normalize(r, err)
+	code := TwirpCodeForStatus(err.StatusCode())
+	var c interface{ TwirpCode() string }
+	if errors.As(err, &c) && c.TwirpCode() != "" {
+		code = c.TwirpCode()
+	}
+
+	writeHeader(w, r, withStatus(err, TwirpStatusForCode(code)), "application/json")
+
+	data, _ := json.Marshal(TwirpErrorResponse{
+		Code: code,
+		Msg:  publicMessage(err),
+		Meta: errorMetadata(err),
+	})
+	w.Write(data)
+}
+
+// TwirpCodeForStatus maps an HTTP status code onto a Twirp error code
+func TwirpCodeForStatus(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return "invalid_argument"
+	case http.StatusUnauthorized:
+		return "unauthenticated"
+	case http.StatusForbidden:
+		return "permission_denied"
+	case http.StatusNotFound:
+		return "not_found"
+	case http.StatusMethodNotAllowed:
+		return "bad_route"
+	case http.StatusRequestTimeout, http.StatusGatewayTimeout:
+		return "deadline_exceeded"
+	case http.StatusConflict:
+		return "aborted"
+	case http.StatusPreconditionFailed:
+		return "failed_precondition"
+	case http.StatusRequestedRangeNotSatisfiable:
+		return "out_of_range"
+	case http.StatusTooManyRequests:
+		return "resource_exhausted"
+	case 499:
+		return "canceled"
+	case http.StatusNotImplemented:
+		return "unimplemented"
+	case http.StatusServiceUnavailable:
+		return "unavailable"
+	}
+	if status >= 500 {
+		return "internal"
+	}
+	return "unknown"
+}
+
+// TwirpStatusForCode maps a Twirp error code onto the HTTP status Twirp documents for it
+func TwirpStatusForCode(code string) int {
+	switch code {
+	case "canceled", "deadline_exceeded":
+		return http.StatusRequestTimeout
+	case "invalid_argument", "malformed", "out_of_range":
+		return http.StatusBadRequest
+	case "not_found", "bad_route":
+		return http.StatusNotFound
+	case "already_exists", "aborted":
+		return http.StatusConflict
+	case "permission_denied":
+		return http.StatusForbidden
+	case "unauthenticated":
+		return http.StatusUnauthorized
+	case "resource_exhausted":
+		return http.StatusTooManyRequests
+	case "failed_precondition":
+		return http.StatusPreconditionFailed
+	case "unimplemented":
+		return http.StatusNotImplemented
+	case "unavailable":
+		return http.StatusServiceUnavailable
+	default:
+		// unknown, internal, dataloss and anything unrecognised
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,79 @@
+package httperrorfmt
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// rateLimitHeaders are the rate limit headers carried over from upstream
+// responses: the IETF RateLimit fields and the common X-RateLimit variant
+var rateLimitHeaders = []string{
+	"RateLimit", "RateLimit-Policy",
+	"RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset",
+	"X-RateLimit-Limit", "X-RateLimit-Remaining", "X-RateLimit-Reset",
+}
+
+// Upstream repackages failed responses of upstream services, keeping the
+// retry and rate limit hints clients need to back off
+type Upstream struct {
+	// Jitter adds a random delay of up to Jitter to Retry-After, so clients
+	// throttled together don't all come back at the same moment
+	Jitter time.Duration
+}
+
+// UpstreamError repackages a failed upstream response without jitter. See Upstream.Error.
+func UpstreamError(resp *http.Response, message string) *Error {
+	return Upstream{}.Error(resp, message)
+}
+
+// Error creates an error for a failed upstream response. 429 and 503 keep
+// their status along with Retry-After and the rate limit headers; any other
+// failure becomes a 502. Retry-After is always sent as delay-seconds, since
+// an HTTP date refers to the upstream's clock.
+func (u Upstream) Error(resp *http.Response, message string) *Error {
+	status := http.StatusBadGateway
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable {
+		status = resp.StatusCode
+	}
+	e := Wrap(fmt.Errorf("upstream responded %s", resp.Status), status, message)
+	if status == http.StatusBadGateway {
+		return e
+	}
+
+	if delay, ok := RetryAfter(resp.Header); ok {
+		if u.Jitter > 0 {
+			delay += rand.N(u.Jitter)
+		}
+		e.WithRetryAfter(delay)
+	}
+	for _, name := range rateLimitHeaders {
+		if value := resp.Header.Get(name); value != "" && validateHeader(name, value) == nil {
+			e.WithHeader(name, value)
+		}
+	}
+	return e
+}
+
+// RetryAfter parses the Retry-After header of h, given as delay-seconds or
+// as an HTTP date, into the delay from now. Dates in the past yield zero.
+func RetryAfter(h http.Header) (time.Duration, bool) {
+	value := strings.TrimSpace(h.Get("Retry-After"))
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	date, err := http.ParseTime(value)
+	if err != nil {
+		return 0, false
+	}
+	return max(time.Until(date), 0), true
+}
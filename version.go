@@ -0,0 +1,97 @@
+package httperrorfmt
+
+import (
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// VersionSelector picks the API version of a request so the negotiator can
+// choose between formatter sets registered per version
+type VersionSelector interface {
+	SelectVersion(r *http.Request) string
+}
+
+// VersionSelectorFunc adapts a function to VersionSelector
+type VersionSelectorFunc func(r *http.Request) string
+
+// SelectVersion calls f(r)
+func (f VersionSelectorFunc) SelectVersion(r *http.Request) string { return f(r) }
+
+// HeaderVersion selects the version named in a request header such as
+// API-Version. Responses then vary on that header.
+func HeaderVersion(name string) VersionSelector {
+	return headerVersion(http.CanonicalHeaderKey(name))
+}
+
+// headerVersion selects the version from the named request header
+type headerVersion string
+
+// SelectVersion returns the header value
+func (h headerVersion) SelectVersion(r *http.Request) string {
+	return strings.TrimSpace(r.Header.Get(string(h)))
+}
+
+// pathVersionPattern matches a leading version segment such as /v2/
+var pathVersionPattern = regexp.MustCompile(`^/(v\d+)(/|$)`)
+
+// PathVersion selects the version from a leading path segment, "v2" for /v2/users
+func PathVersion() VersionSelector {
+	return VersionSelectorFunc(func(r *http.Request) string {
+		if r.URL == nil {
+			return ""
+		}
+		if m := pathVersionPattern.FindStringSubmatch(r.URL.Path); m != nil {
+			return m[1]
+		}
+		return ""
+	})
+}
+
+// vendorVersionPattern matches the version in a vendor media type such as
+// application/vnd.example.v2+json
+var vendorVersionPattern = regexp.MustCompile(`(?i)application/vnd\.[^,;]*\.(v\d+)(\+[a-z]+)?\b`)
+
+// VendorVersion selects the version from a vendor media type in the Accept
+// header, "v2" for application/vnd.example.v2+json
+func VendorVersion() VersionSelector {
+	return VersionSelectorFunc(func(r *http.Request) string {
+		if m := vendorVersionPattern.FindStringSubmatch(r.Header.Get("Accept")); m != nil {
+			return strings.ToLower(m[1])
+		}
+		return ""
+	})
+}
+
+// SetVersionSelector sets how the API version of a request is found
+func (cn *ContentNegotiator) SetVersionSelector(selector VersionSelector) *ContentNegotiator {
+	cn.versionSelector = selector
+	return cn
+}
+
+// RegisterVersion adds the negotiator that formats errors for requests of an
+// API version. Requests whose version has no negotiator use cn itself. Vendor
+// media types used for versioning must be registered on the version's
+// negotiator to be matched there.
+func (cn *ContentNegotiator) RegisterVersion(version string, negotiator *ContentNegotiator) *ContentNegotiator {
+	if cn.versions == nil {
+		cn.versions = make(map[string]*ContentNegotiator)
+	}
+	cn.versions[version] = negotiator
+	return cn
+}
+
+// versioned returns the negotiator registered for the request's version, if any
+func (cn *ContentNegotiator) versioned(w http.ResponseWriter, r *http.Request) *ContentNegotiator {
+	if cn == nil || r == nil || cn.versionSelector == nil {
+		return nil
+	}
+	if h, ok := cn.versionSelector.(headerVersion); ok {
+		addVary(w.Header(), string(h))
+	}
+	version := cn.versionSelector.SelectVersion(r)
+	if version == "" {
+		return nil
+	}
+	return cn.versions[version]
+}
@@ -0,0 +1,96 @@
+package httperrorfmt
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+// VndErrorFormatter formats errors as application/vnd.error+json
+type VndErrorFormatter struct {
+	PrettyPrint bool
+	// Links are added to every response, keyed by relation (e.g. "help", "describes")
+	Links map[string]string
+	// OmitAbout disables the "about" link pointing at the requested resource
+	OmitAbout bool
+	// Resolver adds per-error links, overriding static Links with the same relation
+	Resolver LinkResolver
+}
+
+// VndErrorResponse represents a vnd.error response
+type VndErrorResponse struct {
+	Message string             `json:"message"`
+	Path    string             `json:"path,omitempty"`
+	Logref  string             `json:"logref,omitempty"`
+	Links   map[string]VndLink `json:"_links,omitempty"`
+}
+
+// VndLink represents a link object inside _links
+type VndLink struct {
+	Href string `json:"href"`
+}
+
+// Format implements Formatter interface for vnd.error responses.
+// Errors implementing Path() string or Logref() string have those members filled in.
+func (f *VndErrorFormatter) Format(w http.ResponseWriter, r *http.Request, err HTTPError) {
+	r, err = normalize(r, err)
+	writeHeader(w, r, err, "application/vnd.error+json")
+
+	response := VndErrorResponse{
+		Message: publicMessage(err),
+		Path:    errorPath(err),
+		Logref:  errorLogref(r, err),
+		Links:   f.links(r, err),
+	}
+
+	var data []byte
+	if f.PrettyPrint {
+		data, _ = json.MarshalIndent(response, "", "  ")
+	} else {
+		data, _ = json.Marshal(response)
+	}
+
+	w.Write(data)
+}
+
+// links collects the _links for a response
+func (f *VndErrorFormatter) links(r *http.Request, err HTTPError) map[string]VndLink {
+	links := make(map[string]VndLink, len(f.Links)+1)
+	if !f.OmitAbout && r.URL != nil {
+		links["about"] = VndLink{Href: r.URL.RequestURI()}
+	}
+	for rel, href := range f.Links {
+		links[rel] = VndLink{Href: href}
+	}
+	if f.Resolver != nil {
+		for rel, href := range f.Resolver.ResolveLinks(r, err) {
+			links[rel] = VndLink{Href: href}
+		}
+	}
+	if len(links) == 0 {
+		return nil
+	}
+	return links
+}
+
+// errorPath returns the JSON Pointer the error refers to, if any
+func errorPath(err HTTPError) string {
+	var p interface{ Path() string }
+	if errors.As(err, &p) {
+		return p.Path()
+	}
+	return ""
+}
+
+// errorLogref returns the server side log reference of the error, falling back
+// to the error id when error ids are enabled
+func errorLogref(r *http.Request, err HTTPError) string {
+	var l interface{ Logref() string }
+	if errors.As(err, &l) {
+		return l.Logref()
+	}
+	if s := settingsFrom(r); s.features.ErrorIDs {
+		return s.errorIDFor(err, s.features.IDs)
+	}
+	return ""
+}
@@ -0,0 +1,122 @@
+package httperrorfmt
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+)
+
+// warmer is implemented by formatters with startup work of their own
+type warmer interface {
+	Warmup() error
+}
+
+// Warmup prepares every formatter the negotiator can reach, including
+// defaults, prefix and status rules, the gRPC formatter and version
+// negotiators, so the first real error doesn't pay for template escaping and
+// encoder setup. Each formatter renders a sample error to nowhere, and
+// formatters with a Warmup method run it. Aliases whose content type has no
+// formatter are reported. Bodies are still rendered per error; nothing is
+// cached. Failures are returned together.
+func (cn *ContentNegotiator) Warmup() error {
+	var errs []error
+	reachable := cn.reachable()
+	for _, name := range slices.Sorted(maps.Keys(reachable)) {
+		if err := warmFormatter(reachable[name]); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+		}
+	}
+	for _, alias := range slices.Sorted(maps.Keys(cn.aliases)) {
+		if _, ok := cn.formatters[cn.aliases[alias]]; !ok {
+			errs = append(errs, fmt.Errorf("alias %s: no formatter for %s", alias, cn.aliases[alias]))
+		}
+	}
+	for _, version := range slices.Sorted(maps.Keys(cn.versions)) {
+		if err := cn.versions[version].Warmup(); err != nil {
+			errs = append(errs, fmt.Errorf("version %s: %w", version, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// reachable returns the formatters of the negotiator by a descriptive name
+func (cn *ContentNegotiator) reachable() map[string]Formatter {
+	formatters := make(map[string]Formatter)
+	for contentType, formatter := range cn.formatters {
+		formatters[contentType] = formatter
+	}
+	for prefix, formatter := range cn.prefixes {
+		formatters["prefix "+prefix] = formatter
+	}
+	for status, formatter := range cn.statuses {
+		formatters["status "+strconv.Itoa(status)] = formatter
+	}
+	if cn.defaults != nil {
+		formatters["default"] = cn.defaults
+	}
+	if cn.grpc {
+		formatters["grpc"] = &GRPCFormatter{}
+	}
+	return formatters
+}
+
+// Warmup executes the template once so html/template escapes it up front, and
+// reports template errors that Format would silently swallow
+func (f *HTMLFormatter) Warmup() error {
+	if f.Template == nil {
+		return nil
+	}
+	data := TemplateData{Error: "warmup", Status: http.StatusInternalServerError, Code: statusText(http.StatusInternalServerError)}
+	if f.TemplateName != "" {
+		return f.Template.ExecuteTemplate(io.Discard, f.TemplateName, data)
+	}
+	return f.Template.Execute(io.Discard, data)
+}
+
+// Warmup warms every status specific formatter and the default
+func (f *StatusFormatter) Warmup() error {
+	var errs []error
+	for _, status := range slices.Sorted(maps.Keys(f.Formatters)) {
+		if err := warmFormatter(f.Formatters[status]); err != nil {
+			errs = append(errs, fmt.Errorf("status %d: %w", status, err))
+		}
+	}
+	if f.Default != nil {
+		if err := warmFormatter(f.Default); err != nil {
+			errs = append(errs, fmt.Errorf("default: %w", err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// warmFormatter renders a sample error through f, recovering panics, and
+// runs its Warmup method when it has one
+func warmFormatter(f Formatter) (err error) {
+	if w, ok := f.(warmer); ok {
+		if err := w.Warmup(); err != nil {
+			return err
+		}
+	}
+
+	defer func() {
+		if v := recover(); v != nil {
+			err = fmt.Errorf("panic while formatting: %v", v)
+		}
+	}()
+	r, _ := http.NewRequest(http.MethodGet, "/", nil)
+	f.Format(discardWriter{header: make(http.Header)}, r, New(http.StatusInternalServerError, "warmup"))
+	return nil
+}
+
+// discardWriter is a ResponseWriter that drops everything
+type discardWriter struct {
+	header http.Header
+}
+
+func (w discardWriter) Header() http.Header         { return w.header }
+func (w discardWriter) Write(p []byte) (int, error) { return len(p), nil }
+func (w discardWriter) WriteHeader(int)             {}
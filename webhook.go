@@ -0,0 +1,214 @@
+package httperrorfmt
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// WebhookNotifier posts a JSON summary of selected errors to a webhook. Its
+// Notify method is a Hook; posting happens on a background goroutine so
+// formatting is never slowed down. Notifications that can't be queued are
+// dropped. Call Close on shutdown to stop the goroutine.
+type WebhookNotifier struct {
+	URL string
+	// Client posts the notifications. Nil means a client with a 10 second timeout.
+	Client *http.Client
+	// Statuses selects errors by status; classes such as 5 cover 500-599.
+	// Empty means 5xx unless Codes is set.
+	Statuses []int
+	// Codes selects errors by application error code
+	Codes []string
+	// DedupWindow sends an error with the same status, code and message only
+	// once within the window
+	DedupWindow time.Duration
+	// RateLimit caps the notifications sent per minute. Zero means no limit.
+	RateLimit int
+	// Slack posts {"text": "..."} payloads for Slack compatible incoming webhooks
+	Slack bool
+	// OnFailure is called when a notification can't be delivered
+	OnFailure func(err error)
+
+	start  sync.Once
+	queue  chan WebhookEvent
+	done   chan struct{}
+	mu     sync.Mutex
+	closed bool
+	seen   map[string]time.Time
+	minute time.Time
+	sent   int
+}
+
+// WebhookEvent is the summary posted for an error
+type WebhookEvent struct {
+	Time     time.Time `json:"time"`
+	Status   int       `json:"status"`
+	Code     string    `json:"code,omitempty"`
+	Message  string    `json:"message"`
+	Internal string    `json:"internal,omitempty"`
+	Method   string    `json:"method"`
+	Path     string    `json:"path"`
+	ErrorID  string    `json:"error_id,omitempty"`
+}
+
+// Notify queues a notification for err if it is selected, not a duplicate and
+// within the rate limit
+func (n *WebhookNotifier) Notify(r *http.Request, err HTTPError) {
+	if !n.selects(err) {
+		return
+	}
+	event := WebhookEvent{
+		Time:     time.Now().UTC(),
+		Status:   err.StatusCode(),
+		Code:     internalErrorCode(err),
+		Message:  publicMessage(err),
+		Internal: internalMessage(err),
+		Method:   r.Method,
+	}
+	if r.URL != nil {
+		event.Path = r.URL.Path
+	}
+	if id, ok := ownErrorID(err); ok {
+		event.ErrorID = id
+	}
+
+	// Only queued notifications count towards deduplication and the rate limit
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.closed || !n.admit(event) {
+		return
+	}
+	n.start.Do(n.init)
+	select {
+	case n.queue <- event:
+		n.record(event)
+	default:
+	}
+}
+
+// Close stops accepting notifications and waits for the queued ones to be
+// posted
+func (n *WebhookNotifier) Close() {
+	n.mu.Lock()
+	if n.closed {
+		n.mu.Unlock()
+		return
+	}
+	n.closed = true
+	n.start.Do(n.init)
+	close(n.queue)
+	n.mu.Unlock()
+	<-n.done
+}
+
+// init creates the queue and starts the goroutine posting from it
+func (n *WebhookNotifier) init() {
+	n.queue = make(chan WebhookEvent, 64)
+	n.done = make(chan struct{})
+	go n.run()
+}
+
+// selects reports whether err is one the notifier is configured for
+func (n *WebhookNotifier) selects(err HTTPError) bool {
+	status := err.StatusCode()
+	if len(n.Codes) > 0 && slices.Contains(n.Codes, internalErrorCode(err)) {
+		return true
+	}
+	statuses := n.Statuses
+	if len(statuses) == 0 && len(n.Codes) == 0 {
+		statuses = []int{5}
+	}
+	return slices.Contains(statuses, status) || slices.Contains(statuses, status/100)
+}
+
+// admit applies deduplication and the rate limit. The caller holds n.mu.
+func (n *WebhookNotifier) admit(event WebhookEvent) bool {
+	now := event.Time
+	if n.DedupWindow > 0 {
+		if last, ok := n.seen[dedupKey(event)]; ok && now.Sub(last) < n.DedupWindow {
+			return false
+		}
+	}
+	if n.RateLimit > 0 && now.Sub(n.minute) < time.Minute && n.sent >= n.RateLimit {
+		return false
+	}
+	return true
+}
+
+// record counts a queued notification for deduplication and the rate
+// limit. The caller holds n.mu.
+func (n *WebhookNotifier) record(event WebhookEvent) {
+	now := event.Time
+	if n.DedupWindow > 0 {
+		if n.seen == nil {
+			n.seen = make(map[string]time.Time)
+		}
+		for k, last := range n.seen {
+			if now.Sub(last) >= n.DedupWindow {
+				delete(n.seen, k)
+			}
+		}
+		n.seen[dedupKey(event)] = now
+	}
+	if n.RateLimit > 0 {
+		if now.Sub(n.minute) >= time.Minute {
+			n.minute, n.sent = now, 0
+		}
+		n.sent++
+	}
+}
+
+// dedupKey identifies notifications that are duplicates of each other
+func dedupKey(event WebhookEvent) string {
+	return strconv.Itoa(event.Status) + "\x00" + event.Code + "\x00" + event.Message
+}
+
+// run posts queued notifications
+func (n *WebhookNotifier) run() {
+	client := n.Client
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	defer close(n.done)
+	for event := range n.queue {
+		if err := n.post(client, event); err != nil && n.OnFailure != nil {
+			n.OnFailure(err)
+		}
+	}
+}
+
+// post sends one notification
+func (n *WebhookNotifier) post(client *http.Client, event WebhookEvent) error {
+	var payload any = event
+	if n.Slack {
+		text := fmt.Sprintf("%d %s on %s %s: %s", event.Status, statusText(event.Status), event.Method, event.Path, event.Internal)
+		if event.Code != "" {
+			text += " (" + event.Code + ")"
+		}
+		if event.ErrorID != "" {
+			text += "\nError ID: " + event.ErrorID
+		}
+		payload = map[string]string{"text": text}
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Post(n.URL, "application/json", bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("httperrorfmt: webhook: %w", err)
+	}
+	// Drain the body so the connection can be reused
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("httperrorfmt: webhook responded %s", resp.Status)
+	}
+	return nil
+}